@@ -12,6 +12,7 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	JWT      JWTConfig
+	Job      JobConfig
 }
 
 // ServerConfig 服务器配置
@@ -41,6 +42,13 @@ type JWTConfig struct {
 	ExpireTime int // 过期时间（小时）
 }
 
+// JobConfig 异步生成任务配置
+type JobConfig struct {
+	Workers    int // 同时执行的任务数量
+	QueueSize  int // 最多排队的任务数量
+	MaxResults int // 单个任务最多生成的结果数量（预计结果数量超过时拒绝提交）
+}
+
 var AppConfig *Config
 
 // LoadConfig 加载配置
@@ -75,6 +83,11 @@ func LoadConfig() *Config {
 			Secret:     getEnv("JWT_SECRET", "sayhi-secret-key-change-in-production"),
 			ExpireTime: getEnvAsInt("JWT_EXPIRE_TIME", 24), // 24小时
 		},
+		Job: JobConfig{
+			Workers:    getEnvAsInt("JOB_WORKERS", 2),
+			QueueSize:  getEnvAsInt("JOB_QUEUE_SIZE", 100),
+			MaxResults: getEnvAsInt("JOB_MAX_RESULTS", 10000000),
+		},
 	}
 
 	AppConfig = config
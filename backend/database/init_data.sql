@@ -11,6 +11,14 @@ INSERT INTO `users` (`username`, `password`) VALUES
 ('user', '6ad14ba9986e3615423dfca256d04e3f')
 ON DUPLICATE KEY UPDATE `username`=`username`;
 
+-- 插入默认位置
+INSERT INTO `positions` (`name`, `label`, `sort_order`) VALUES
+('a', '位置 A', 1),
+('b', '位置 B', 2),
+('c', '位置 C', 3),
+('d', '位置 D', 4)
+ON DUPLICATE KEY UPDATE `name`=`name`;
+
 -- 插入示例位置值
 INSERT INTO `position_values` (`position`, `value`, `sort_order`) VALUES
 ('a', '1', 1),
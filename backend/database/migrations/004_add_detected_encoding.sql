@@ -0,0 +1,9 @@
+-- 迁移脚本 004: 位置值和话术记录检测到的编码
+-- 执行时间: 2026-10-18
+-- 说明: 为 position_values 和 speeches 添加 encoding 字段，保存自动检测到的缅甸文编码
+
+ALTER TABLE `position_values`
+  ADD COLUMN `encoding` VARCHAR(20) NOT NULL DEFAULT 'Unicode' COMMENT '检测到的编码（Zawgyi/Unicode）' AFTER `value`;
+
+ALTER TABLE `speeches`
+  ADD COLUMN `encoding` VARCHAR(20) NOT NULL DEFAULT 'Unicode' COMMENT '检测到的编码（Zawgyi/Unicode）' AFTER `content`;
@@ -0,0 +1,30 @@
+-- 迁移脚本 005: 添加位置定义表
+-- 执行时间: 2026-10-18
+-- 说明: 位置不再限定为 a-d，改为在 positions 表中定义，位置数量不限
+
+CREATE TABLE IF NOT EXISTS `positions` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `name` VARCHAR(50) NOT NULL COMMENT '位置标识',
+  `label` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '显示名称',
+  `description` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '描述',
+  `sort_order` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '排序顺序',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_name` (`name`),
+  KEY `idx_sort_order` (`sort_order`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='位置定义表';
+
+ALTER TABLE `position_values`
+  MODIFY COLUMN `position` VARCHAR(50) NOT NULL COMMENT '位置标识（positions.name）';
+
+-- 原有的 a-d 位置，以及已存在位置值的位置
+INSERT INTO `positions` (`name`, `label`, `sort_order`) VALUES
+('a', '位置 A', 1),
+('b', '位置 B', 2),
+('c', '位置 C', 3),
+('d', '位置 D', 4)
+ON DUPLICATE KEY UPDATE `name`=`name`;
+
+INSERT IGNORE INTO `positions` (`name`, `label`, `sort_order`)
+SELECT DISTINCT `position`, CONCAT('位置 ', UPPER(`position`)), 100 FROM `position_values`;
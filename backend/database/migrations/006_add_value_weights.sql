@@ -0,0 +1,9 @@
+-- 迁移脚本 006: 位置值和话术添加权重
+-- 执行时间: 2026-10-18
+-- 说明: 随机生成和抽样时按权重抽取位置值和话术，默认权重为1（等权）
+
+ALTER TABLE `position_values`
+  ADD COLUMN `weight` DOUBLE NOT NULL DEFAULT 1 COMMENT '随机生成时的权重' AFTER `encoding`;
+
+ALTER TABLE `speeches`
+  ADD COLUMN `weight` DOUBLE NOT NULL DEFAULT 1 COMMENT '随机生成时的权重' AFTER `encoding`;
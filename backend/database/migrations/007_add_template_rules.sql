@@ -0,0 +1,17 @@
+-- 迁移脚本 007: 添加模板约束规则表
+-- 执行时间: 2026-10-18
+-- 说明: 保存模板的位置约束规则（excludes/requires），生成时跳过不满足规则的组合
+
+CREATE TABLE IF NOT EXISTS `template_rules` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '规则ID',
+  `template_id` BIGINT UNSIGNED NOT NULL COMMENT '模板ID',
+  `position` VARCHAR(50) NOT NULL COMMENT '条件位置',
+  `value` VARCHAR(500) NOT NULL COMMENT '条件值',
+  `rule_type` VARCHAR(20) NOT NULL COMMENT '规则类型：excludes/requires',
+  `target_position` VARCHAR(50) NOT NULL COMMENT '目标位置',
+  `target_value` VARCHAR(500) NOT NULL COMMENT '目标值',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_template_id` (`template_id`),
+  CONSTRAINT `fk_template_rules_template` FOREIGN KEY (`template_id`) REFERENCES `templates` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='模板约束规则表';
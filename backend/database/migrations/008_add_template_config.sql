@@ -0,0 +1,6 @@
+-- 迁移脚本 008: 模板表添加生成参数字段
+-- 执行时间: 2026-10-18
+-- 说明: 保存模板时同时保存完整的生成参数（编码、话术组绑定、生成方式、最大字符数等），JSON 格式
+
+ALTER TABLE `templates`
+  ADD COLUMN `config` MEDIUMTEXT DEFAULT NULL COMMENT '生成参数（JSON）' AFTER `encoding`;
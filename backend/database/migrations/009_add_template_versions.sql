@@ -0,0 +1,24 @@
+-- 迁移脚本 009: 添加模板版本表
+-- 执行时间: 2026-10-18
+-- 说明: 记录模板每次修改后的完整内容（不可修改），支持查看差异和恢复；已有模板补记为版本1（未记录约束规则）
+
+CREATE TABLE IF NOT EXISTS `template_versions` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '记录ID',
+  `template_id` BIGINT UNSIGNED NOT NULL COMMENT '模板ID',
+  `version` INT NOT NULL COMMENT '版本号（从1开始）',
+  `name` VARCHAR(100) NOT NULL COMMENT '模板名称',
+  `template` TEXT NOT NULL COMMENT '模板内容',
+  `encoding` VARCHAR(20) NOT NULL DEFAULT 'Unicode' COMMENT '字符编码',
+  `config` MEDIUMTEXT DEFAULT NULL COMMENT '生成参数（JSON）',
+  `rules` TEXT DEFAULT NULL COMMENT '约束规则（JSON，为空表示未记录）',
+  `author_id` BIGINT UNSIGNED NOT NULL COMMENT '修改人ID',
+  `comment` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '修改说明',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '修改时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_template_version` (`template_id`, `version`),
+  CONSTRAINT `fk_template_versions_template` FOREIGN KEY (`template_id`) REFERENCES `templates` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='模板版本表';
+
+INSERT INTO `template_versions` (`template_id`, `version`, `name`, `template`, `encoding`, `config`, `rules`, `author_id`, `comment`, `created_at`)
+SELECT `id`, 1, `name`, `template`, `encoding`, `config`, NULL, `user_id`, '迁移', `updated_at`
+FROM `templates`;
@@ -0,0 +1,14 @@
+-- 迁移脚本 010: 生成历史记录表添加生成详情字段
+-- 执行时间: 2026-10-18
+-- 说明: 每次生成都记录到生成历史，保存各位置编码、种子、耗时、使用的已保存模板和完整的生成参数，支持按原参数重新生成
+
+ALTER TABLE `generate_history`
+  MODIFY COLUMN `generate_mode` VARCHAR(20) NOT NULL COMMENT '生成方式（sequential/random/sample）',
+  MODIFY COLUMN `total_count` BIGINT UNSIGNED NOT NULL COMMENT '组合总数',
+  ADD COLUMN `encodings` TEXT DEFAULT NULL COMMENT '实际使用的各位置编码（JSON）' AFTER `encoding`,
+  ADD COLUMN `seed` BIGINT DEFAULT NULL COMMENT '随机种子' AFTER `generate_mode`,
+  ADD COLUMN `result_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '返回的结果数量' AFTER `total_count`,
+  ADD COLUMN `duration_ms` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '生成耗时（毫秒）' AFTER `exceeded_count`,
+  ADD COLUMN `template_id` BIGINT UNSIGNED DEFAULT NULL COMMENT '使用的已保存模板ID' AFTER `duration_ms`,
+  ADD COLUMN `template_version` INT DEFAULT NULL COMMENT '使用的已保存模板版本号' AFTER `template_id`,
+  ADD COLUMN `request` MEDIUMTEXT DEFAULT NULL COMMENT '完整的生成参数（JSON）' AFTER `template_version`;
@@ -0,0 +1,31 @@
+-- 迁移脚本 011: 添加异步生成任务表
+-- 执行时间: 2026-10-18
+-- 说明: 大批量生成改为后台任务执行，保存任务状态、进度和生成结果，供之后分页查看或下载
+
+CREATE TABLE IF NOT EXISTS `generate_jobs` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '任务ID',
+  `user_id` BIGINT UNSIGNED NOT NULL COMMENT '提交用户ID',
+  `status` VARCHAR(20) NOT NULL COMMENT '状态（queued/running/succeeded/failed/canceled）',
+  `request` MEDIUMTEXT NOT NULL COMMENT '生成参数（JSON）',
+  `seed` BIGINT DEFAULT NULL COMMENT '随机种子',
+  `total_count` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '组合总数',
+  `expected_count` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '预计结果数量',
+  `result_count` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '已生成的结果数量',
+  `exceeded_count` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '超出限制的数量',
+  `error` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '失败原因',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '提交时间',
+  `started_at` DATETIME DEFAULT NULL COMMENT '开始时间',
+  `finished_at` DATETIME DEFAULT NULL COMMENT '结束时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_status` (`status`),
+  CONSTRAINT `fk_jobs_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='异步生成任务表';
+
+CREATE TABLE IF NOT EXISTS `generate_job_results` (
+  `job_id` BIGINT UNSIGNED NOT NULL COMMENT '任务ID',
+  `seq` BIGINT UNSIGNED NOT NULL COMMENT '结果序号（从0开始，按生成顺序）',
+  `result` TEXT NOT NULL COMMENT '生成结果（JSON）',
+  PRIMARY KEY (`job_id`, `seq`),
+  CONSTRAINT `fk_job_results_job` FOREIGN KEY (`job_id`) REFERENCES `generate_jobs` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='异步生成任务结果表';
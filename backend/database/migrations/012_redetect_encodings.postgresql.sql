@@ -0,0 +1,9 @@
+-- 迁移脚本 012（PostgreSQL）: 重新检测位置值的编码
+-- 执行时间: 2026-10-18
+-- 说明: 与 012_redetect_encodings.sql 相同（该版本的建表脚本没有话术表，只处理位置值）。
+--       将 encoding 默认值改为空字符串（表示待检测），
+--       并把已有记录重置为待检测，服务启动时会按内容重新检测并保存（检测为 Zawgyi 的记录会写入日志）
+
+ALTER TABLE position_values ALTER COLUMN encoding SET DEFAULT '';
+
+UPDATE position_values SET encoding = '';
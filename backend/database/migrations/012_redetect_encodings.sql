@@ -0,0 +1,14 @@
+-- 迁移脚本 012: 重新检测位置值和话术的编码
+-- 执行时间: 2026-10-18
+-- 说明: 迁移 004 为已有记录统一填入了 Unicode，其中的 Zawgyi 内容被误标（检测为 Zawgyi 的记录会写入日志）。
+--       将 encoding 默认值改为空字符串（表示待检测），并把已有记录重置为待检测，
+--       服务启动时会按内容重新检测并保存（读取时遇到空值也会即时检测）
+
+ALTER TABLE `position_values`
+  MODIFY COLUMN `encoding` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '检测到的编码（Zawgyi/Unicode，为空表示待检测）';
+
+ALTER TABLE `speeches`
+  MODIFY COLUMN `encoding` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '检测到的编码（Zawgyi/Unicode，为空表示待检测）';
+
+UPDATE `position_values` SET `encoding` = '';
+UPDATE `speeches` SET `encoding` = '';
@@ -0,0 +1,8 @@
+-- 迁移脚本 012（SQLite）: 重新检测位置值的编码
+-- 执行时间: 2026-10-18
+-- 说明: 与 012_redetect_encodings.sql 相同（该版本的建表脚本没有话术表，只处理位置值）。
+--       SQLite 不能修改已有字段的默认值，
+--       新增记录时程序总是写入检测到的编码，因此只需把已有记录重置为待检测，
+--       服务启动时会按内容重新检测并保存（检测为 Zawgyi 的记录会写入日志）
+
+UPDATE position_values SET encoding = '';
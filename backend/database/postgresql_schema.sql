@@ -13,13 +13,30 @@ CREATE TABLE IF NOT EXISTS users (
 
 CREATE INDEX idx_users_username ON users(username);
 
+-- ============================================
+-- 位置定义表
+-- ============================================
+CREATE TABLE IF NOT EXISTS positions (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR(50) NOT NULL UNIQUE,
+  label VARCHAR(100) NOT NULL DEFAULT '',
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  sort_order INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_positions_sort_order ON positions(sort_order);
+
 -- ============================================
 -- 位置值配置表
 -- ============================================
 CREATE TABLE IF NOT EXISTS position_values (
   id BIGSERIAL PRIMARY KEY,
-  position VARCHAR(10) NOT NULL,
+  position VARCHAR(50) NOT NULL,
   value VARCHAR(500) NOT NULL,
+  encoding VARCHAR(20) NOT NULL DEFAULT '',
+  weight DOUBLE PRECISION NOT NULL DEFAULT 1,
   sort_order INTEGER NOT NULL DEFAULT 0,
   created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
@@ -36,6 +53,7 @@ CREATE TABLE IF NOT EXISTS templates (
   name VARCHAR(100) NOT NULL,
   template TEXT NOT NULL,
   encoding VARCHAR(20) NOT NULL DEFAULT 'Unicode',
+  config TEXT,
   user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
   created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
@@ -43,6 +61,40 @@ CREATE TABLE IF NOT EXISTS templates (
 
 CREATE INDEX idx_templates_user_id ON templates(user_id);
 
+-- ============================================
+-- 模板约束规则表
+-- ============================================
+CREATE TABLE IF NOT EXISTS template_rules (
+  id BIGSERIAL PRIMARY KEY,
+  template_id BIGINT NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+  position VARCHAR(50) NOT NULL,
+  value VARCHAR(500) NOT NULL,
+  rule_type VARCHAR(20) NOT NULL,
+  target_position VARCHAR(50) NOT NULL,
+  target_value VARCHAR(500) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_template_rules_template_id ON template_rules(template_id);
+
+-- ============================================
+-- 模板版本表（不可修改的历史记录）
+-- ============================================
+CREATE TABLE IF NOT EXISTS template_versions (
+  id BIGSERIAL PRIMARY KEY,
+  template_id BIGINT NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+  version INTEGER NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  template TEXT NOT NULL,
+  encoding VARCHAR(20) NOT NULL DEFAULT 'Unicode',
+  config TEXT,
+  rules TEXT,
+  author_id BIGINT NOT NULL,
+  comment VARCHAR(200) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (template_id, version)
+);
+
 -- ============================================
 -- 生成历史记录表
 -- ============================================
@@ -51,15 +103,54 @@ CREATE TABLE IF NOT EXISTS generate_history (
   user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
   template TEXT NOT NULL,
   encoding VARCHAR(20) NOT NULL,
+  encodings TEXT,
   generate_mode VARCHAR(20) NOT NULL,
-  total_count INTEGER NOT NULL,
+  seed BIGINT,
+  total_count BIGINT NOT NULL,
+  result_count INTEGER NOT NULL DEFAULT 0,
   exceeded_count INTEGER NOT NULL DEFAULT 0,
+  duration_ms BIGINT NOT NULL DEFAULT 0,
+  template_id BIGINT,
+  template_version INTEGER,
+  request TEXT,
   created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE INDEX idx_generate_history_user_id ON generate_history(user_id);
 CREATE INDEX idx_generate_history_created_at ON generate_history(created_at);
 
+-- ============================================
+-- 异步生成任务表
+-- ============================================
+CREATE TABLE IF NOT EXISTS generate_jobs (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status VARCHAR(20) NOT NULL,
+  request TEXT NOT NULL,
+  seed BIGINT,
+  total_count BIGINT NOT NULL DEFAULT 0,
+  expected_count BIGINT NOT NULL DEFAULT 0,
+  result_count BIGINT NOT NULL DEFAULT 0,
+  exceeded_count BIGINT NOT NULL DEFAULT 0,
+  error VARCHAR(500) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_generate_jobs_user_id ON generate_jobs(user_id);
+CREATE INDEX idx_generate_jobs_status ON generate_jobs(status);
+
+-- ============================================
+-- 异步生成任务结果表
+-- ============================================
+CREATE TABLE IF NOT EXISTS generate_job_results (
+  job_id BIGINT NOT NULL REFERENCES generate_jobs(id) ON DELETE CASCADE,
+  seq BIGINT NOT NULL,
+  result TEXT NOT NULL,
+  PRIMARY KEY (job_id, seq)
+);
+
 -- ============================================
 -- 触发器：自动更新 updated_at
 -- ============================================
@@ -74,6 +165,9 @@ $$ language 'plpgsql';
 CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+CREATE TRIGGER update_positions_updated_at BEFORE UPDATE ON positions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
 CREATE TRIGGER update_position_values_updated_at BEFORE UPDATE ON position_values
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
@@ -88,6 +182,13 @@ INSERT INTO users (username, password) VALUES
 ('user', '6ad14ba9986e3615423dfca256d04e3f')
 ON CONFLICT (username) DO NOTHING;
 
+INSERT INTO positions (name, label, sort_order) VALUES
+('a', '位置 A', 1),
+('b', '位置 B', 2),
+('c', '位置 C', 3),
+('d', '位置 D', 4)
+ON CONFLICT (name) DO NOTHING;
+
 INSERT INTO position_values (position, value, sort_order) VALUES
 ('a', '1', 1),
 ('b', 'baidu.com', 1),
@@ -15,13 +15,31 @@ CREATE TABLE IF NOT EXISTS `users` (
   KEY `idx_username` (`username`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户表';
 
+-- ============================================
+-- 位置定义表
+-- ============================================
+CREATE TABLE IF NOT EXISTS `positions` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `name` VARCHAR(50) NOT NULL COMMENT '位置标识',
+  `label` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '显示名称',
+  `description` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '描述',
+  `sort_order` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '排序顺序',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_name` (`name`),
+  KEY `idx_sort_order` (`sort_order`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='位置定义表';
+
 -- ============================================
 -- 位置值配置表
 -- ============================================
 CREATE TABLE IF NOT EXISTS `position_values` (
   `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'ID',
-  `position` VARCHAR(10) NOT NULL COMMENT '位置标识（a, b, c, d）',
+  `position` VARCHAR(50) NOT NULL COMMENT '位置标识（positions.name）',
   `value` VARCHAR(500) NOT NULL COMMENT '位置值',
+  `encoding` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '检测到的编码（Zawgyi/Unicode，为空表示待检测）',
+  `weight` DOUBLE NOT NULL DEFAULT 1 COMMENT '随机生成时的权重',
   `sort_order` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '排序顺序',
   `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
@@ -38,6 +56,7 @@ CREATE TABLE IF NOT EXISTS `templates` (
   `name` VARCHAR(100) NOT NULL COMMENT '模板名称',
   `template` TEXT NOT NULL COMMENT '模板内容',
   `encoding` VARCHAR(20) NOT NULL DEFAULT 'Unicode' COMMENT '字符编码',
+  `config` MEDIUMTEXT DEFAULT NULL COMMENT '生成参数（JSON）',
   `user_id` BIGINT UNSIGNED NOT NULL COMMENT '创建用户ID',
   `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
@@ -46,6 +65,43 @@ CREATE TABLE IF NOT EXISTS `templates` (
   CONSTRAINT `fk_templates_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='模板配置表';
 
+-- ============================================
+-- 模板约束规则表
+-- ============================================
+CREATE TABLE IF NOT EXISTS `template_rules` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '规则ID',
+  `template_id` BIGINT UNSIGNED NOT NULL COMMENT '模板ID',
+  `position` VARCHAR(50) NOT NULL COMMENT '条件位置',
+  `value` VARCHAR(500) NOT NULL COMMENT '条件值',
+  `rule_type` VARCHAR(20) NOT NULL COMMENT '规则类型：excludes/requires',
+  `target_position` VARCHAR(50) NOT NULL COMMENT '目标位置',
+  `target_value` VARCHAR(500) NOT NULL COMMENT '目标值',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_template_id` (`template_id`),
+  CONSTRAINT `fk_template_rules_template` FOREIGN KEY (`template_id`) REFERENCES `templates` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='模板约束规则表';
+
+-- ============================================
+-- 模板版本表（不可修改的历史记录）
+-- ============================================
+CREATE TABLE IF NOT EXISTS `template_versions` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '记录ID',
+  `template_id` BIGINT UNSIGNED NOT NULL COMMENT '模板ID',
+  `version` INT NOT NULL COMMENT '版本号（从1开始）',
+  `name` VARCHAR(100) NOT NULL COMMENT '模板名称',
+  `template` TEXT NOT NULL COMMENT '模板内容',
+  `encoding` VARCHAR(20) NOT NULL DEFAULT 'Unicode' COMMENT '字符编码',
+  `config` MEDIUMTEXT DEFAULT NULL COMMENT '生成参数（JSON）',
+  `rules` TEXT DEFAULT NULL COMMENT '约束规则（JSON，为空表示未记录）',
+  `author_id` BIGINT UNSIGNED NOT NULL COMMENT '修改人ID',
+  `comment` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '修改说明',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '修改时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_template_version` (`template_id`, `version`),
+  CONSTRAINT `fk_template_versions_template` FOREIGN KEY (`template_id`) REFERENCES `templates` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='模板版本表';
+
 -- ============================================
 -- 话术组表
 -- ============================================
@@ -67,6 +123,8 @@ CREATE TABLE IF NOT EXISTS `speeches` (
   `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '话术ID',
   `group_id` BIGINT UNSIGNED NOT NULL COMMENT '话术组ID',
   `content` VARCHAR(500) NOT NULL COMMENT '话术内容',
+  `encoding` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '检测到的编码（Zawgyi/Unicode，为空表示待检测）',
+  `weight` DOUBLE NOT NULL DEFAULT 1 COMMENT '随机生成时的权重',
   `sort_order` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '排序顺序',
   `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   PRIMARY KEY (`id`),
@@ -83,9 +141,16 @@ CREATE TABLE IF NOT EXISTS `generate_history` (
   `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
   `template` TEXT NOT NULL COMMENT '使用的模板',
   `encoding` VARCHAR(20) NOT NULL COMMENT '字符编码',
-  `generate_mode` VARCHAR(20) NOT NULL COMMENT '生成方式（sequential/random）',
-  `total_count` INT UNSIGNED NOT NULL COMMENT '生成总数',
+  `encodings` TEXT DEFAULT NULL COMMENT '实际使用的各位置编码（JSON）',
+  `generate_mode` VARCHAR(20) NOT NULL COMMENT '生成方式（sequential/random/sample）',
+  `seed` BIGINT DEFAULT NULL COMMENT '随机种子',
+  `total_count` BIGINT UNSIGNED NOT NULL COMMENT '组合总数',
+  `result_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '返回的结果数量',
   `exceeded_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '超出数量',
+  `duration_ms` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '生成耗时（毫秒）',
+  `template_id` BIGINT UNSIGNED DEFAULT NULL COMMENT '使用的已保存模板ID',
+  `template_version` INT DEFAULT NULL COMMENT '使用的已保存模板版本号',
+  `request` MEDIUMTEXT DEFAULT NULL COMMENT '完整的生成参数（JSON）',
   `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   PRIMARY KEY (`id`),
   KEY `idx_user_id` (`user_id`),
@@ -93,6 +158,40 @@ CREATE TABLE IF NOT EXISTS `generate_history` (
   CONSTRAINT `fk_history_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='生成历史记录表';
 
+-- ============================================
+-- 异步生成任务表
+-- ============================================
+CREATE TABLE IF NOT EXISTS `generate_jobs` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '任务ID',
+  `user_id` BIGINT UNSIGNED NOT NULL COMMENT '提交用户ID',
+  `status` VARCHAR(20) NOT NULL COMMENT '状态（queued/running/succeeded/failed/canceled）',
+  `request` MEDIUMTEXT NOT NULL COMMENT '生成参数（JSON）',
+  `seed` BIGINT DEFAULT NULL COMMENT '随机种子',
+  `total_count` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '组合总数',
+  `expected_count` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '预计结果数量',
+  `result_count` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '已生成的结果数量',
+  `exceeded_count` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '超出限制的数量',
+  `error` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '失败原因',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '提交时间',
+  `started_at` DATETIME DEFAULT NULL COMMENT '开始时间',
+  `finished_at` DATETIME DEFAULT NULL COMMENT '结束时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_status` (`status`),
+  CONSTRAINT `fk_jobs_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='异步生成任务表';
+
+-- ============================================
+-- 异步生成任务结果表
+-- ============================================
+CREATE TABLE IF NOT EXISTS `generate_job_results` (
+  `job_id` BIGINT UNSIGNED NOT NULL COMMENT '任务ID',
+  `seq` BIGINT UNSIGNED NOT NULL COMMENT '结果序号（从0开始，按生成顺序）',
+  `result` TEXT NOT NULL COMMENT '生成结果（JSON）',
+  PRIMARY KEY (`job_id`, `seq`),
+  CONSTRAINT `fk_job_results_job` FOREIGN KEY (`job_id`) REFERENCES `generate_jobs` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='异步生成任务结果表';
+
 -- ============================================
 -- 初始化默认数据
 -- ============================================
@@ -103,6 +202,14 @@ INSERT INTO `users` (`username`, `password`) VALUES
 ('user', '6ad14ba9986e3615423dfca256d04e3f')
 ON DUPLICATE KEY UPDATE `username`=`username`;
 
+-- 插入默认位置
+INSERT INTO `positions` (`name`, `label`, `sort_order`) VALUES
+('a', '位置 A', 1),
+('b', '位置 B', 2),
+('c', '位置 C', 3),
+('d', '位置 D', 4)
+ON DUPLICATE KEY UPDATE `name`=`name`;
+
 -- 插入示例位置值
 INSERT INTO `position_values` (`position`, `value`, `sort_order`) VALUES
 ('a', '1', 1),
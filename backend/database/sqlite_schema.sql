@@ -13,6 +13,21 @@ CREATE TABLE IF NOT EXISTS users (
 
 CREATE INDEX idx_users_username ON users(username);
 
+-- ============================================
+-- 位置定义表
+-- ============================================
+CREATE TABLE IF NOT EXISTS positions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL UNIQUE,
+  label TEXT NOT NULL DEFAULT '',
+  description TEXT NOT NULL DEFAULT '',
+  sort_order INTEGER NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_positions_sort_order ON positions(sort_order);
+
 -- ============================================
 -- 位置值配置表
 -- ============================================
@@ -20,6 +35,8 @@ CREATE TABLE IF NOT EXISTS position_values (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
   position TEXT NOT NULL,
   value TEXT NOT NULL,
+  encoding TEXT NOT NULL DEFAULT '',
+  weight REAL NOT NULL DEFAULT 1,
   sort_order INTEGER NOT NULL DEFAULT 0,
   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
@@ -36,6 +53,7 @@ CREATE TABLE IF NOT EXISTS templates (
   name TEXT NOT NULL,
   template TEXT NOT NULL,
   encoding TEXT NOT NULL DEFAULT 'Unicode',
+  config TEXT,
   user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
@@ -43,6 +61,40 @@ CREATE TABLE IF NOT EXISTS templates (
 
 CREATE INDEX idx_templates_user_id ON templates(user_id);
 
+-- ============================================
+-- 模板约束规则表
+-- ============================================
+CREATE TABLE IF NOT EXISTS template_rules (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  template_id INTEGER NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+  position TEXT NOT NULL,
+  value TEXT NOT NULL,
+  rule_type TEXT NOT NULL,
+  target_position TEXT NOT NULL,
+  target_value TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_template_rules_template_id ON template_rules(template_id);
+
+-- ============================================
+-- 模板版本表（不可修改的历史记录）
+-- ============================================
+CREATE TABLE IF NOT EXISTS template_versions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  template_id INTEGER NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+  version INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  template TEXT NOT NULL,
+  encoding TEXT NOT NULL DEFAULT 'Unicode',
+  config TEXT,
+  rules TEXT,
+  author_id INTEGER NOT NULL,
+  comment TEXT NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (template_id, version)
+);
+
 -- ============================================
 -- 生成历史记录表
 -- ============================================
@@ -51,15 +103,54 @@ CREATE TABLE IF NOT EXISTS generate_history (
   user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
   template TEXT NOT NULL,
   encoding TEXT NOT NULL,
+  encodings TEXT,
   generate_mode TEXT NOT NULL,
+  seed INTEGER,
   total_count INTEGER NOT NULL,
+  result_count INTEGER NOT NULL DEFAULT 0,
   exceeded_count INTEGER NOT NULL DEFAULT 0,
+  duration_ms INTEGER NOT NULL DEFAULT 0,
+  template_id INTEGER,
+  template_version INTEGER,
+  request TEXT,
   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE INDEX idx_generate_history_user_id ON generate_history(user_id);
 CREATE INDEX idx_generate_history_created_at ON generate_history(created_at);
 
+-- ============================================
+-- 异步生成任务表
+-- ============================================
+CREATE TABLE IF NOT EXISTS generate_jobs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status TEXT NOT NULL,
+  request TEXT NOT NULL,
+  seed INTEGER,
+  total_count INTEGER NOT NULL DEFAULT 0,
+  expected_count INTEGER NOT NULL DEFAULT 0,
+  result_count INTEGER NOT NULL DEFAULT 0,
+  exceeded_count INTEGER NOT NULL DEFAULT 0,
+  error TEXT NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  started_at DATETIME,
+  finished_at DATETIME
+);
+
+CREATE INDEX idx_generate_jobs_user_id ON generate_jobs(user_id);
+CREATE INDEX idx_generate_jobs_status ON generate_jobs(status);
+
+-- ============================================
+-- 异步生成任务结果表
+-- ============================================
+CREATE TABLE IF NOT EXISTS generate_job_results (
+  job_id INTEGER NOT NULL REFERENCES generate_jobs(id) ON DELETE CASCADE,
+  seq INTEGER NOT NULL,
+  result TEXT NOT NULL,
+  PRIMARY KEY (job_id, seq)
+);
+
 -- ============================================
 -- 触发器：自动更新 updated_at
 -- ============================================
@@ -70,6 +161,13 @@ BEGIN
   UPDATE users SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
 END;
 
+CREATE TRIGGER IF NOT EXISTS update_positions_updated_at 
+  AFTER UPDATE ON positions
+  FOR EACH ROW
+BEGIN
+  UPDATE positions SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
 CREATE TRIGGER IF NOT EXISTS update_position_values_updated_at 
   AFTER UPDATE ON position_values
   FOR EACH ROW
@@ -91,6 +189,12 @@ INSERT OR IGNORE INTO users (username, password) VALUES
 ('admin', '0192023a7bbd73250516f069df18b500'),
 ('user', '6ad14ba9986e3615423dfca256d04e3f');
 
+INSERT OR IGNORE INTO positions (name, label, sort_order) VALUES
+('a', '位置 A', 1),
+('b', '位置 B', 2),
+('c', '位置 C', 3),
+('d', '位置 D', 4);
+
 INSERT OR IGNORE INTO position_values (position, value, sort_order) VALUES
 ('a', '1', 1),
 ('b', 'baidu.com', 1),
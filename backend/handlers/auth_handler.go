@@ -103,3 +103,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	})
 }
 
+// currentUserID 获取当前登录用户的ID，未认证时返回401并返回 false
+func currentUserID(c *gin.Context) (int64, bool) {
+	value, exists := c.Get("user")
+	user, ok := value.(*models.User)
+	if !exists || !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未认证",
+		})
+		return 0, false
+	}
+	return user.ID, true
+}
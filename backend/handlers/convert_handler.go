@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"sayhi/backend/models"
+	"sayhi/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConvertHandler 编码转换处理器
+type ConvertHandler struct {
+	service *services.ConverterService
+}
+
+// NewConvertHandler 创建编码转换处理器
+func NewConvertHandler(service *services.ConverterService) *ConvertHandler {
+	return &ConvertHandler{
+		service: service,
+	}
+}
+
+// Convert 转换缅甸文编码（Zawgyi ⇄ Unicode）
+func (h *ConvertHandler) Convert(c *gin.Context) {
+	var req models.ConvertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	results, err := h.service.ConvertAll(req.Texts, req.From, req.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ConvertResponse{
+		Results: results,
+		From:    req.From,
+		To:      req.To,
+	})
+}
+
+// Detect 检测缅甸文编码（Zawgyi 或 Unicode）
+func (h *ConvertHandler) Detect(c *gin.Context) {
+	var req models.DetectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DetectResponse{
+		Results: h.service.Detect(req.Texts),
+	})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"sayhi/backend/models"
+	"sayhi/backend/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultHistoryPageSize = 20
+	maxHistoryPageSize     = 100
+)
+
+// HistoryHandler 生成历史处理器
+type HistoryHandler struct {
+	service *services.HistoryService
+}
+
+// NewHistoryHandler 创建生成历史处理器
+func NewHistoryHandler(service *services.HistoryService) *HistoryHandler {
+	return &HistoryHandler{
+		service: service,
+	}
+}
+
+// ListHistory 查询当前用户的生成历史
+// 查询参数：from、to 按时间过滤（YYYY-MM-DD 或 RFC3339，只有日期时 to 包含当天）；
+// page、pageSize 分页（默认第1页，每页20条，最多100条）
+func (h *HistoryHandler) ListHistory(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	filter := services.HistoryFilter{
+		Page:     1,
+		PageSize: defaultHistoryPageSize,
+	}
+
+	var err error
+	if filter.From, err = parseHistoryTime(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的起始时间: " + c.Query("from"),
+		})
+		return
+	}
+	if filter.To, err = parseHistoryTime(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的结束时间: " + c.Query("to"),
+		})
+		return
+	}
+
+	if value := c.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的页码",
+			})
+			return
+		}
+		filter.Page = page
+	}
+	if value := c.Query("pageSize"); value != "" {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil || pageSize <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的每页条数",
+			})
+			return
+		}
+		if pageSize > maxHistoryPageSize {
+			pageSize = maxHistoryPageSize
+		}
+		filter.PageSize = pageSize
+	}
+
+	history, total, err := h.service.ListHistory(userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.HistoryListResponse{
+		History:  history,
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	})
+}
+
+// GetHistory 获取当前用户的一条生成历史（包含完整的生成参数）
+func (h *HistoryHandler) GetHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的ID",
+		})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.service.GetHistory(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// parseHistoryTime 解析时间过滤参数，为空时返回零值
+// 只有日期的结束时间取次日零点，使查询结果包含当天
+func parseHistoryTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
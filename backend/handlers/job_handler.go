@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sayhi/backend/models"
+	"sayhi/backend/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultJobResultsLimit = 100
+	maxJobResultsLimit     = 1000
+	jobEventInterval       = 500 * time.Millisecond
+)
+
+// JobHandler 异步生成任务处理器
+type JobHandler struct {
+	service         *services.JobService
+	templateService *services.TemplateService
+}
+
+// NewJobHandler 创建异步生成任务处理器
+func NewJobHandler(service *services.JobService, templateService *services.TemplateService) *JobHandler {
+	return &JobHandler{
+		service:         service,
+		templateService: templateService,
+	}
+}
+
+// CreateJob 提交生成任务，请求体与生成接口相同，立即返回排队中的任务
+func (h *JobHandler) CreateJob(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := bindTemplateRequest(c, h.templateService, userID)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.Submit(userID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrJobQueueFull) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrJobTooLarge) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if respondTemplateError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetAllJobs 获取当前用户的所有任务
+func (h *JobHandler) GetAllJobs(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.service.GetAllJobs(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.JobListResponse{
+		Jobs:  jobs,
+		Total: len(jobs),
+	})
+}
+
+// GetJob 获取任务状态和进度
+func (h *JobHandler) GetJob(c *gin.Context) {
+	id, userID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.GetJob(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// Events 以 Server-Sent Events 推送任务进度
+// 状态或结果数量变化时发送 progress 事件，任务结束时发送 done 事件并关闭连接
+func (h *JobHandler) Events(c *gin.Context) {
+	id, userID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.service.GetJob(id, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(jobEventInterval)
+	defer ticker.Stop()
+
+	var last *models.GenerateJob
+	c.Stream(func(w io.Writer) bool {
+		job, err := h.service.GetJob(id, userID)
+		if err != nil {
+			c.SSEvent("error", gin.H{"error": err.Error()})
+			return false
+		}
+		job.Request = nil
+
+		if job.Finished() {
+			c.SSEvent("done", job)
+			return false
+		}
+		if last == nil || last.Status != job.Status || last.ResultCount != job.ResultCount {
+			c.SSEvent("progress", job)
+			last = job
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+// CancelJob 取消排队或生成中的任务
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	id, userID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.CancelJob(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DeleteJob 删除任务及其结果
+func (h *JobHandler) DeleteJob(c *gin.Context) {
+	id, userID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteJob(id, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除成功",
+	})
+}
+
+// GetResults 分页获取任务的结果，查询参数 offset（默认0）和 limit（默认100，最多1000）
+func (h *JobHandler) GetResults(c *gin.Context) {
+	id, userID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的 offset 参数",
+		})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultJobResultsLimit)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的 limit 参数",
+		})
+		return
+	}
+	if limit > maxJobResultsLimit {
+		limit = maxJobResultsLimit
+	}
+
+	response, err := h.service.GetResults(id, userID, offset, limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Download 下载任务的全部结果，format 和 bom 参数同导出接口
+// 结果超过 MaxXLSXRows 条的任务不能下载为 Excel
+func (h *JobHandler) Download(c *gin.Context) {
+	format, bom, ok := parseExportParams(c)
+	if !ok {
+		return
+	}
+	id, userID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.GetJob(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	// 下载开始后无法再返回错误，超出 Excel 行数上限的任务预先拒绝
+	if format == models.ExportXLSX && job.ResultCount > services.MaxXLSXRows {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("任务结果共 %d 条，超过 Excel 工作表最多 %d 行的限制，请使用其他格式下载", job.ResultCount, services.MaxXLSXRows),
+		})
+		return
+	}
+	if job.Seed != nil {
+		c.Header("X-Seed", strconv.FormatInt(*job.Seed, 10))
+	}
+
+	writer, err := startDownload(c, format, bom, fmt.Sprintf("sms-job-%d", id))
+	if err == nil {
+		err = h.service.StreamResults(id, userID, writer.WriteResult)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	// 响应已经开始写出，只能记录错误
+	if err != nil {
+		log.Println("下载任务结果失败: " + err.Error())
+	}
+}
+
+// parseJobID 读取路径中的任务ID和当前用户，失败时已写出错误响应
+func parseJobID(c *gin.Context) (int64, int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的ID",
+		})
+		return 0, 0, false
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return 0, 0, false
+	}
+	return id, userID, true
+}
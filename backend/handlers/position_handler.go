@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"sayhi/backend/models"
 	"sayhi/backend/services"
+	"sayhi/backend/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,7 +25,10 @@ func NewPositionHandler(service *services.PositionService) *PositionHandler {
 func (h *PositionHandler) GetAllPositions(c *gin.Context) {
 	positions := h.service.GetAllPositions()
 	c.JSON(http.StatusOK, models.PositionValueListResponse{
-		Positions: positions,
+		Positions:   positions,
+		Encodings:   h.service.GetAllPositionEncodings(),
+		Weights:     h.service.GetAllPositionWeights(),
+		Definitions: h.service.GetDefinitions(),
 	})
 }
 
@@ -38,10 +42,19 @@ func (h *PositionHandler) GetPositionValues(c *gin.Context) {
 		return
 	}
 
+	definition, err := h.service.GetDefinition(position)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	values := h.service.GetPositionValues(position)
 	c.JSON(http.StatusOK, gin.H{
-		"position": position,
-		"values":   values,
+		"position":   position,
+		"definition": definition,
+		"values":     values,
 	})
 }
 
@@ -62,7 +75,25 @@ func (h *PositionHandler) AddPositionValue(c *gin.Context) {
 		return
 	}
 
-	h.service.AddPositionValue(req.Position, req.Value)
+	if !h.service.PositionExists(req.Position) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "位置不存在: " + req.Position,
+		})
+		return
+	}
+
+	weight := services.DefaultWeight
+	if req.Weight != nil {
+		weight = *req.Weight
+	}
+	if err := services.ValidateWeights([]float64{weight}, 1); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.service.AddPositionValue(req.Position, req.Value, weight)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "添加成功",
 	})
@@ -78,8 +109,16 @@ func (h *PositionHandler) SetPositionValues(c *gin.Context) {
 		return
 	}
 
+	if !h.service.PositionExists(position) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "位置不存在: " + position,
+		})
+		return
+	}
+
 	var req struct {
-		Values []string `json:"values" binding:"required"`
+		Values  []string  `json:"values" binding:"required"`
+		Weights []float64 `json:"weights"` // 可选，与 values 一一对应
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -88,7 +127,14 @@ func (h *PositionHandler) SetPositionValues(c *gin.Context) {
 		return
 	}
 
-	h.service.SetPositionValues(position, req.Values)
+	if err := services.ValidateWeights(req.Weights, len(req.Values)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.service.SetPositionValues(position, req.Values, req.Weights)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "设置成功",
 	})
@@ -113,19 +159,105 @@ func (h *PositionHandler) DeletePositionValue(c *gin.Context) {
 		return
 	}
 
+	if !h.service.PositionExists(position) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "位置不存在: " + position,
+		})
+		return
+	}
+
 	h.service.DeletePositionValue(position, value)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "删除成功",
 	})
 }
 
-func isValidPosition(position string) bool {
-	validPositions := []string{"a", "b", "c", "d"}
-	for _, p := range validPositions {
-		if p == position {
-			return true
-		}
+// CreatePosition 创建位置
+func (h *PositionHandler) CreatePosition(c *gin.Context) {
+	var req models.PositionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if !isValidPosition(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的位置标识",
+		})
+		return
 	}
-	return false
+
+	position, err := h.service.CreatePosition(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, position)
 }
 
+// UpdatePosition 更新位置定义（名称、显示名称、描述、排序）
+func (h *PositionHandler) UpdatePosition(c *gin.Context) {
+	position := c.Param("position")
+	if !h.service.PositionExists(position) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "位置不存在: " + position,
+		})
+		return
+	}
+
+	var req models.PositionUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Name != "" && !isValidPosition(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的位置标识",
+		})
+		return
+	}
+
+	updated, err := h.service.UpdatePosition(position, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeletePosition 删除位置及其所有值
+func (h *PositionHandler) DeletePosition(c *gin.Context) {
+	position := c.Param("position")
+	if err := h.service.DeletePosition(position); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除成功",
+	})
+}
+
+// maxPositionNameLength 位置标识的最大长度（与数据库字段长度一致）
+const maxPositionNameLength = 50
+
+// isValidPosition 检查位置标识格式：与命名占位符名称的规则相同，且不超过最大长度；
+// "definitions" 为路由保留字
+func isValidPosition(position string) bool {
+	return utils.IsValidPlaceholderName(position) &&
+		len(position) <= maxPositionNameLength &&
+		position != "definitions"
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sayhi/backend/models"
+	"sayhi/backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RuleHandler 模板约束规则处理器
+type RuleHandler struct {
+	service         *services.RuleService
+	templateService *services.TemplateService
+}
+
+// NewRuleHandler 创建模板约束规则处理器
+func NewRuleHandler(service *services.RuleService, templateService *services.TemplateService) *RuleHandler {
+	return &RuleHandler{
+		service:         service,
+		templateService: templateService,
+	}
+}
+
+// GetRules 获取模板的约束规则
+func (h *RuleHandler) GetRules(c *gin.Context) {
+	templateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的ID",
+		})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.service.GetRules(templateID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ConstraintRuleListResponse{
+		TemplateID: templateID,
+		Rules:      rules,
+	})
+}
+
+// SetRules 设置模板的约束规则（整体替换，并记录模板的新版本）
+func (h *RuleHandler) SetRules(c *gin.Context) {
+	templateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的ID",
+		})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ConstraintRuleListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := validateRules(req.Rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	rules, err := h.templateService.SetRules(templateID, userID, req.Rules)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ConstraintRuleListResponse{
+		TemplateID: templateID,
+		Rules:      rules,
+	})
+}
+
+// validateRules 校验约束规则的类型和位置标识
+func validateRules(rules []models.ConstraintRule) error {
+	for i, rule := range rules {
+		if rule.Type != models.RuleExcludes && rule.Type != models.RuleRequires {
+			return fmt.Errorf("第%d条规则的类型无效，只能是 excludes 或 requires", i+1)
+		}
+		if !isValidPosition(rule.Position) || !isValidPosition(rule.TargetPosition) {
+			return fmt.Errorf("第%d条规则的位置标识无效", i+1)
+		}
+		if rule.Position == rule.TargetPosition {
+			return fmt.Errorf("第%d条规则的条件位置和目标位置不能相同", i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"net/http"
+	"sayhi/backend/models"
+	"sayhi/backend/services"
+	"sayhi/backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SavedTemplateHandler 已保存模板处理器
+type SavedTemplateHandler struct {
+	service *services.TemplateService
+}
+
+// NewSavedTemplateHandler 创建已保存模板处理器
+func NewSavedTemplateHandler(service *services.TemplateService) *SavedTemplateHandler {
+	return &SavedTemplateHandler{
+		service: service,
+	}
+}
+
+// CreateTemplate 保存模板
+func (h *SavedTemplateHandler) CreateTemplate(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SavedTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if !validateTemplateConfig(c, &req.Config) {
+		return
+	}
+
+	tpl, err := h.service.CreateTemplate(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tpl)
+}
+
+// GetTemplate 获取模板
+func (h *SavedTemplateHandler) GetTemplate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的ID",
+		})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	tpl, err := h.service.GetTemplate(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tpl)
+}
+
+// GetAllTemplates 获取当前用户的所有模板
+func (h *SavedTemplateHandler) GetAllTemplates(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	templates := h.service.GetAllTemplates(userID)
+	c.JSON(http.StatusOK, models.SavedTemplateListResponse{
+		Templates: templates,
+		Total:     len(templates),
+	})
+}
+
+// UpdateTemplate 更新模板
+func (h *SavedTemplateHandler) UpdateTemplate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的ID",
+		})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SavedTemplateUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Config != nil && !validateTemplateConfig(c, req.Config) {
+		return
+	}
+
+	tpl, err := h.service.UpdateTemplate(id, userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tpl)
+}
+
+// DeleteTemplate 删除模板
+func (h *SavedTemplateHandler) DeleteTemplate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的ID",
+		})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteTemplate(id, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除成功",
+	})
+}
+
+// GetVersions 获取模板的历史版本
+func (h *SavedTemplateHandler) GetVersions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的ID",
+		})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.service.GetVersions(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TemplateVersionListResponse{
+		TemplateID: id,
+		Versions:   versions,
+		Total:      len(versions),
+	})
+}
+
+// GetVersion 获取模板的指定版本
+func (h *SavedTemplateHandler) GetVersion(c *gin.Context) {
+	id, version, ok := parseTemplateVersion(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	v, err := h.service.GetVersion(id, version, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, v)
+}
+
+// DiffVersions 比较模板的两个版本（查询参数 from 必填，to 默认为最新版本）
+func (h *SavedTemplateHandler) DiffVersions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的ID",
+		})
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的起始版本号",
+		})
+		return
+	}
+	to := 0
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = strconv.Atoi(toStr); err != nil || to <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的目标版本号",
+			})
+			return
+		}
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	diff, err := h.service.DiffVersions(id, from, to, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// RestoreVersion 将模板恢复为指定版本
+func (h *SavedTemplateHandler) RestoreVersion(c *gin.Context) {
+	id, version, ok := parseTemplateVersion(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	tpl, err := h.service.RestoreVersion(id, version, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tpl)
+}
+
+// parseTemplateVersion 解析路径中的模板ID和版本号，无效时返回400并返回 false
+func parseTemplateVersion(c *gin.Context) (int64, int, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的ID",
+		})
+		return 0, 0, false
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的版本号",
+		})
+		return 0, 0, false
+	}
+	return id, version, true
+}
+
+// validateTemplateConfig 验证要保存的生成参数和模板语法，失败时返回400并返回 false
+func validateTemplateConfig(c *gin.Context, config *models.TemplateRequest) bool {
+	if err := validateTemplateRequest(config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return false
+	}
+
+	if config.Template != "" {
+		if _, err := utils.ParseTemplateSegments(config.Template); err != nil {
+			if !respondTemplateError(c, err) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+			}
+			return false
+		}
+	}
+	return true
+}
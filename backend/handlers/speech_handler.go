@@ -31,6 +31,13 @@ func (h *SpeechHandler) CreateGroup(c *gin.Context) {
 		return
 	}
 
+	if err := services.ValidateWeights(req.Weights, len(req.Speeches)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	group, err := h.service.CreateGroup(&req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -92,6 +99,13 @@ func (h *SpeechHandler) UpdateGroup(c *gin.Context) {
 		return
 	}
 
+	if err := services.ValidateWeights(req.Weights, len(req.Speeches)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	group, err := h.service.UpdateGroup(id, &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -1,102 +1,405 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"sayhi/backend/models"
 	"sayhi/backend/services"
+	"sayhi/backend/utils"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 // TemplateHandler 模板处理器
 type TemplateHandler struct {
-	generator *services.TemplateGenerator
+	generator       *services.TemplateGenerator
+	templateService *services.TemplateService
+	historyService  *services.HistoryService
 }
 
 // NewTemplateHandler 创建模板处理器
-func NewTemplateHandler(speechService *services.SpeechService) *TemplateHandler {
+func NewTemplateHandler(speechService *services.SpeechService, templateService *services.TemplateService, historyService *services.HistoryService) *TemplateHandler {
 	return &TemplateHandler{
-		generator: services.NewTemplateGenerator(speechService),
+		generator:       services.NewTemplateGenerator(speechService),
+		templateService: templateService,
+		historyService:  historyService,
 	}
 }
 
 // Generate 生成短信内容
+// 请求体携带 templateId 时，以已保存模板（templateVersion 指定的版本，默认最新版本）的生成参数为基础，
+// 请求体中出现的字段覆盖保存的参数，并同时使用该版本的约束规则；响应中返回实际使用的版本号。
+// 每次生成（offset 为0的第一页）记录到生成历史，翻页请求不重复记录
 func (h *TemplateHandler) Generate(c *gin.Context) {
-	var req models.TemplateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := bindTemplateRequest(c, h.templateService, userID)
+	if !ok {
+		return
+	}
+
+	// 翻页请求属于同一次生成，只在第一页记录
+	h.generate(c, userID, req, req.Offset == 0)
+}
+
+// Export 导出生成结果，请求体与生成接口相同
+// 查询参数 format 指定导出格式（csv、xlsx、txt、ndjson，默认 csv），bom 指定 CSV 和纯文本是否带 UTF-8 BOM
+// （CSV 默认带，纯文本默认不带）。导出从 offset 开始的 limit 条结果（limit 为0时导出全部，Excel 最多 MaxXLSXRows 条），
+// 边生成边写出；随机生成和抽样使用的种子在响应头 X-Seed 中返回。每次导出记录到生成历史
+func (h *TemplateHandler) Export(c *gin.Context) {
+	format, bom, ok := parseExportParams(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := bindTemplateRequest(c, h.templateService, userID)
+	if !ok {
+		return
+	}
+	if format == models.ExportXLSX && (req.Limit == 0 || req.Limit > services.MaxXLSXRows) {
+		req.Limit = services.MaxXLSXRows
+	}
+	// 响应头在写出第一条结果前发送，需要预先确定种子
+	if req.GenerateMode != models.GenerateSequential && req.Seed == nil {
+		seed := time.Now().UnixNano()
+		req.Seed = &seed
+	}
+
+	// 生成参数错误在产出第一条结果前返回，此时还可以返回 JSON 错误，因此响应头在第一条结果时才写出
+	var writer services.ResultWriter
+	open := func() error {
+		if req.GenerateMode != models.GenerateSequential {
+			c.Header("X-Seed", strconv.FormatInt(*req.Seed, 10))
+		}
+		var err error
+		writer, err = startDownload(c, format, bom, "sms-"+time.Now().Format("20060102-150405"))
+		return err
+	}
+
+	var writeErr error
+	count := 0
+	start := time.Now()
+	summary, err := h.generator.Stream(c.Request.Context(), req, func(result models.GeneratedResult) bool {
+		if writer == nil {
+			if writeErr = open(); writeErr != nil {
+				return false
+			}
+		}
+		if writeErr = writer.WriteResult(&result); writeErr != nil {
+			return false
+		}
+		count++
+		return req.Limit <= 0 || count < req.Limit
+	})
+	if err != nil {
+		if respondTemplateError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "导出失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 没有结果时也返回只有表头的文件
+	if writer == nil && writeErr == nil {
+		writeErr = open()
+	}
+	if writer != nil {
+		if err := writer.Close(); writeErr == nil {
+			writeErr = err
+		}
+	}
+	// 响应已经开始写出（如客户端断开连接），只能记录错误
+	if writeErr != nil {
+		log.Println("导出生成结果失败: " + writeErr.Error())
+	}
+
+	if err := h.historyService.Record(userID, req, summary, time.Since(start)); err != nil {
+		log.Println(err)
+	}
+}
+
+// Rerun 按生成历史中保存的参数（包括实际使用的种子和模板版本）重新生成，并记录为新的历史
+func (h *TemplateHandler) Rerun(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "请求参数错误: " + err.Error(),
+			"error": "无效的ID",
 		})
 		return
 	}
 
-	// 验证编码类型（兼容旧版本）
-	if req.Encoding != "" && !isValidEncoding(req.Encoding) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.historyService.GetHistory(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if history.Request == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "无效的编码类型",
+			"error": "该记录没有保存生成参数，无法重新生成",
 		})
 		return
 	}
 
-	// 验证每个位置的编码类型
-	if req.Encodings == nil || len(req.Encodings) == 0 {
-		// 如果没有提供 Encodings，尝试使用旧的 Encoding
-		if req.Encoding == "" {
+	h.generate(c, userID, history.Request, true)
+}
+
+// parseExportParams 读取导出格式（format，默认 csv）和 bom 参数（CSV 默认带 BOM），失败时已写出错误响应
+func parseExportParams(c *gin.Context) (models.ExportFormat, bool, bool) {
+	format := models.ExportFormat(c.DefaultQuery("format", string(models.ExportCSV)))
+	if !services.IsValidExportFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "不支持的导出格式: " + string(format),
+		})
+		return "", false, false
+	}
+	bom := format == models.ExportCSV
+	if value := c.Query("bom"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "必须提供编码配置（encodings）",
+				"error": "无效的 bom 参数: " + value,
+			})
+			return "", false, false
+		}
+		bom = parsed
+	}
+	return format, bom, true
+}
+
+// startDownload 写出文件下载的响应头，返回写入响应的结果写出器，filename 不含扩展名
+func startDownload(c *gin.Context, format models.ExportFormat, bom bool, filename string) (services.ResultWriter, error) {
+	contentType, extension := services.ExportContentType(format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, extension))
+	c.Status(http.StatusOK)
+	return services.NewResultWriter(format, c.Writer, bom)
+}
+
+// bindTemplateRequest 读取并验证生成请求，携带 templateId 时与已保存模板的参数合并
+// 失败时已写出错误响应，返回 false
+func bindTemplateRequest(c *gin.Context, templateService *services.TemplateService, userID int64) (*models.TemplateRequest, bool) {
+	var req models.TemplateRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	if req.TemplateID > 0 {
+		body, _ := c.Get(gin.BodyBytesKey)
+		resolved, err := templateService.ResolveRequest(req.TemplateID, req.TemplateVersion, userID, body.([]byte))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
 			})
+			return nil, false
+		}
+		req = *resolved
+	}
+
+	if err := validateTemplateRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+
+	// 验证分页参数
+	if req.Offset < 0 || req.Limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "分页参数不能为负数",
+		})
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// generate 生成短信内容并返回响应，record 为 true 时记录生成历史
+func (h *TemplateHandler) generate(c *gin.Context, userID int64, req *models.TemplateRequest, record bool) {
+	start := time.Now()
+	response, err := h.generator.Generate(c.Request.Context(), req)
+	if err != nil {
+		if respondTemplateError(c, err) {
 			return
 		}
-		// 兼容旧版本：为所有选中的位置设置相同的编码
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "生成失败: " + err.Error(),
+		})
+		return
+	}
+	if req.TemplateID > 0 {
+		response.TemplateID = req.TemplateID
+		response.TemplateVersion = req.TemplateVersion
+	}
+
+	// 记录失败不影响本次生成结果
+	if record {
+		summary := &services.GenerationSummary{
+			TotalCount:    response.TotalCount,
+			ResultCount:   int64(len(response.Results)),
+			ExceededCount: int64(response.ExceededCount),
+			Seed:          response.Seed,
+			Encodings:     response.Encodings,
+		}
+		if err := h.historyService.Record(userID, req, summary, time.Since(start)); err != nil {
+			log.Println(err)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// validateTemplateRequest 验证生成参数（生成请求和保存模板时共用）
+// 兼容旧版本：未提供 Encodings 时，为所有选中的位置设置 Encoding 指定的编码
+func validateTemplateRequest(req *models.TemplateRequest) error {
+	// 验证编码类型（兼容旧版本）
+	if req.Encoding != "" && !isValidEncoding(req.Encoding) {
+		return errors.New("无效的编码类型")
+	}
+
+	// 兼容旧版本：未提供 Encodings 时，为所有选中的位置设置相同的编码
+	if len(req.Encodings) == 0 && req.Encoding != "" {
 		req.Encodings = make(map[string]models.EncodingType)
 		for _, pos := range req.SelectedPositions {
 			req.Encodings[pos] = req.Encoding
 		}
 	}
 
-	// 验证每个位置的编码是否有效
+	// 验证每个位置的编码是否有效（未指定编码的位置将使用自动检测到的编码）
 	for pos, encoding := range req.Encodings {
 		if !isValidEncoding(encoding) {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": fmt.Sprintf("位置 %s 的编码类型无效", pos),
-			})
-			return
+			return fmt.Errorf("位置 %s 的编码类型无效", pos)
 		}
 	}
 
-	// 确保每个选中的位置都有编码
-	for _, pos := range req.SelectedPositions {
-		if _, exists := req.Encodings[pos]; !exists {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": fmt.Sprintf("位置 %s 缺少编码配置", pos),
-			})
-			return
+	// 验证位置标识（位置数量不限，标识需符合命名规则）
+	for pos := range req.Positions {
+		if !isValidPosition(pos) {
+			return fmt.Errorf("无效的位置标识: %s", pos)
+		}
+	}
+	for _, pos := range append(req.SelectedPositions, req.OptionalPositions...) {
+		if !isValidPosition(pos) {
+			return fmt.Errorf("无效的位置标识: %s", pos)
 		}
 	}
 
+	// 验证权重（位置值在请求中给出时，权重数量必须与值的数量一致）
+	for pos, weights := range req.Weights {
+		count := len(weights)
+		if values, exists := req.Positions[pos]; exists {
+			count = len(values)
+		}
+		if err := services.ValidateWeights(weights, count); err != nil {
+			return fmt.Errorf("位置 %s 的权重无效: %v", pos, err)
+		}
+	}
+
+	// 验证缅甸文输出编码
+	if req.OutputEncoding != "" && !services.IsMyanmarEncoding(req.OutputEncoding) {
+		return errors.New("输出编码只能是 Zawgyi 或 Unicode")
+	}
+
 	// 验证生成方式
 	if !isValidGenerateMode(req.GenerateMode) {
+		return errors.New("无效的生成方式")
+	}
+
+	// 抽样生成必须指定抽样数量
+	if req.GenerateMode == models.GenerateSample && req.SampleSize <= 0 {
+		return errors.New("抽样生成需要指定大于0的抽样数量")
+	}
+
+	if req.MaxSegments < 0 {
+		return errors.New("最大分段数不能为负数")
+	}
+
+	if !isValidDedupMode(req.Dedup) {
+		return errors.New("无效的去重方式")
+	}
+	if err := validateSimilarity(req.Similarity); err != nil {
+		return err
+	}
+	if err := validatePositionOrder(req.PositionOrder); err != nil {
+		return err
+	}
+
+	// 验证约束规则
+	return validateRules(req.Rules)
+}
+
+// Parse 解析模板，返回字面文本与占位符片段，供编辑器校验和高亮
+func (h *TemplateHandler) Parse(c *gin.Context) {
+	var req struct {
+		Template string `json:"template" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "无效的生成方式",
+			"error": "请求参数错误: " + err.Error(),
 		})
 		return
 	}
 
-	response, err := h.generator.Generate(&req)
+	segments, err := utils.ParseTemplateSegments(req.Template)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "生成失败: " + err.Error(),
+		if respondTemplateError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, gin.H{
+		"segments": segments,
+	})
+}
+
+// respondTemplateError 模板语法错误时返回400及出错位置，便于前端标出错误
+func respondTemplateError(c *gin.Context, err error) bool {
+	var templateErr *utils.TemplateError
+	if !errors.As(err, &templateErr) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  templateErr.Error(),
+		"line":   templateErr.Pos.Line,
+		"column": templateErr.Pos.Column,
+		"offset": templateErr.Pos.Offset,
+	})
+	return true
 }
 
 func isValidEncoding(encoding models.EncodingType) bool {
 	switch encoding {
-	case models.EncodingASCII, models.EncodingZawgyi, models.EncodingUnicode, models.EncodingOther:
+	case models.EncodingASCII, models.EncodingGSM7, models.EncodingZawgyi, models.EncodingUnicode, models.EncodingUCS2, models.EncodingOther:
 		return true
 	default:
 		return false
@@ -105,7 +408,75 @@ func isValidEncoding(encoding models.EncodingType) bool {
 
 func isValidGenerateMode(mode models.GenerateMode) bool {
 	switch mode {
-	case models.GenerateSequential, models.GenerateRandom:
+	case models.GenerateSequential, models.GenerateRandom, models.GenerateSample:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateSimilarity 验证近似重复过滤参数
+func validateSimilarity(config *models.SimilarityFilter) error {
+	if config == nil {
+		return nil
+	}
+	switch config.Method {
+	case models.SimilarityEdit:
+		if config.MinDistance != math.Trunc(config.MinDistance) || config.MinDistance < 1 || config.MinDistance > services.MaxEditDistance {
+			return fmt.Errorf("编辑距离的最小距离必须是 1~%d 之间的整数", services.MaxEditDistance)
+		}
+	case models.SimilarityJaccard:
+		if config.MinDistance <= 0 || config.MinDistance > 1 {
+			return errors.New("Jaccard 距离的最小距离必须大于0且不超过1")
+		}
+		if config.NGram < 0 || config.NGram > services.MaxNGram {
+			return fmt.Errorf("n-gram 长度必须在 1~%d 之间", services.MaxNGram)
+		}
+	default:
+		return errors.New("无效的近似重复过滤方式")
+	}
+	return nil
+}
+
+// validatePositionOrder 验证位置顺序约束：位置标识有效，每个位置最多出现一次，相邻位置组至少包含两个位置
+func validatePositionOrder(order *models.PositionOrder) error {
+	if order == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	check := func(positions []string) error {
+		for _, pos := range positions {
+			if !isValidPosition(pos) {
+				return fmt.Errorf("无效的位置标识: %s", pos)
+			}
+			if seen[pos] {
+				return fmt.Errorf("位置 %s 在位置顺序中重复出现", pos)
+			}
+			seen[pos] = true
+		}
+		return nil
+	}
+
+	for _, positions := range [][]string{order.Start, order.End, order.Fixed} {
+		if err := check(positions); err != nil {
+			return err
+		}
+	}
+	for i, group := range order.Groups {
+		if len(group) < 2 {
+			return fmt.Errorf("第%d个相邻位置组至少需要两个位置", i+1)
+		}
+		if err := check(group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isValidDedupMode(mode models.DedupMode) bool {
+	switch mode {
+	case models.DedupNone, models.DedupExact, models.DedupNormalized:
 		return true
 	default:
 		return false
@@ -24,6 +24,13 @@ func main() {
 	defer database.CloseDB()
 	fmt.Println("数据库连接成功")
 
+	// 为尚未检测编码的位置值和话术补齐编码
+	if count, err := services.BackfillEncodings(); err != nil {
+		log.Printf("检测位置值和话术编码失败: %v", err)
+	} else if count > 0 {
+		fmt.Printf("已为 %d 条位置值和话术检测编码\n", count)
+	}
+
 	// 设置Gin模式
 	gin.SetMode(cfg.Server.Mode)
 
@@ -35,18 +42,30 @@ func main() {
 	config.AllowAllOrigins = true
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
 	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	config.ExposeHeaders = []string{"Content-Disposition", "X-Seed"}
 	r.Use(cors.New(config))
 
 	// 初始化服务
 	authService := services.NewAuthService()
 	positionService := services.NewPositionService()
 	speechService := services.NewSpeechService()
+	converterService := services.NewConverterService()
+	ruleService := services.NewRuleService()
+	templateService := services.NewTemplateService(ruleService)
+	historyService := services.NewHistoryService()
+	jobService := services.NewJobService(speechService, historyService, cfg.Job.QueueSize, int64(cfg.Job.MaxResults))
+	jobService.Start(cfg.Job.Workers)
 
 	// 初始化处理器
 	authHandler := handlers.NewAuthHandler(authService)
-	templateHandler := handlers.NewTemplateHandler(speechService)
+	templateHandler := handlers.NewTemplateHandler(speechService, templateService, historyService)
 	positionHandler := handlers.NewPositionHandler(positionService)
 	speechHandler := handlers.NewSpeechHandler(speechService)
+	convertHandler := handlers.NewConvertHandler(converterService)
+	ruleHandler := handlers.NewRuleHandler(ruleService, templateService)
+	savedTemplateHandler := handlers.NewSavedTemplateHandler(templateService)
+	historyHandler := handlers.NewHistoryHandler(historyService)
+	jobHandler := handlers.NewJobHandler(jobService, templateService)
 
 	// 认证中间件
 	authMiddleware := middleware.AuthMiddleware(authService)
@@ -68,6 +87,40 @@ func main() {
 
 		// 模板生成
 		api.POST("/template/generate", templateHandler.Generate)
+		api.POST("/template/parse", templateHandler.Parse)
+		api.POST("/template/export", templateHandler.Export)
+
+		// 异步生成任务
+		api.POST("/jobs", jobHandler.CreateJob)
+		api.GET("/jobs", jobHandler.GetAllJobs)
+		api.GET("/jobs/:id", jobHandler.GetJob)
+		api.GET("/jobs/:id/events", jobHandler.Events)
+		api.POST("/jobs/:id/cancel", jobHandler.CancelJob)
+		api.DELETE("/jobs/:id", jobHandler.DeleteJob)
+		api.GET("/jobs/:id/results", jobHandler.GetResults)
+		api.GET("/jobs/:id/download", jobHandler.Download)
+
+		// 生成历史
+		api.GET("/history", historyHandler.ListHistory)
+		api.GET("/history/:id", historyHandler.GetHistory)
+		api.POST("/history/:id/rerun", templateHandler.Rerun)
+
+		// 已保存的模板
+		api.GET("/templates", savedTemplateHandler.GetAllTemplates)
+		api.GET("/templates/:id", savedTemplateHandler.GetTemplate)
+		api.POST("/templates", savedTemplateHandler.CreateTemplate)
+		api.PUT("/templates/:id", savedTemplateHandler.UpdateTemplate)
+		api.DELETE("/templates/:id", savedTemplateHandler.DeleteTemplate)
+
+		// 模板版本
+		api.GET("/templates/:id/versions", savedTemplateHandler.GetVersions)
+		api.GET("/templates/:id/versions/:version", savedTemplateHandler.GetVersion)
+		api.POST("/templates/:id/versions/:version/restore", savedTemplateHandler.RestoreVersion)
+		api.GET("/templates/:id/diff", savedTemplateHandler.DiffVersions)
+
+		// 模板约束规则
+		api.GET("/templates/:id/rules", ruleHandler.GetRules)
+		api.PUT("/templates/:id/rules", ruleHandler.SetRules)
 
 		// 位置值管理
 		api.GET("/positions", positionHandler.GetAllPositions)
@@ -76,12 +129,21 @@ func main() {
 		api.PUT("/positions/:position", positionHandler.SetPositionValues)
 		api.DELETE("/positions/:position", positionHandler.DeletePositionValue)
 
+		// 位置定义管理
+		api.POST("/positions/definitions", positionHandler.CreatePosition)
+		api.PUT("/positions/:position/definition", positionHandler.UpdatePosition)
+		api.DELETE("/positions/:position/definition", positionHandler.DeletePosition)
+
 		// 话术组管理
 		api.GET("/speech-groups", speechHandler.GetAllGroups)
 		api.GET("/speech-groups/:id", speechHandler.GetGroup)
 		api.POST("/speech-groups", speechHandler.CreateGroup)
 		api.PUT("/speech-groups/:id", speechHandler.UpdateGroup)
 		api.DELETE("/speech-groups/:id", speechHandler.DeleteGroup)
+
+		// 缅甸文编码转换
+		api.POST("/convert/myanmar", convertHandler.Convert)
+		api.POST("/convert/myanmar/detect", convertHandler.Detect)
 	}
 
 	// 健康检查
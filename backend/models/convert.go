@@ -0,0 +1,32 @@
+package models
+
+// ConvertRequest 缅甸文编码转换请求
+type ConvertRequest struct {
+	Texts []string     `json:"texts" binding:"required,min=1"`
+	From  EncodingType `json:"from" binding:"required"` // Zawgyi 或 Unicode
+	To    EncodingType `json:"to" binding:"required"`   // Zawgyi 或 Unicode
+}
+
+// ConvertResponse 缅甸文编码转换响应
+type ConvertResponse struct {
+	Results []string     `json:"results"`
+	From    EncodingType `json:"from"`
+	To      EncodingType `json:"to"`
+}
+
+// DetectRequest 缅甸文编码检测请求
+type DetectRequest struct {
+	Texts []string `json:"texts" binding:"required,min=1"`
+}
+
+// DetectResult 单条文本的编码检测结果
+type DetectResult struct {
+	Text              string       `json:"text"`
+	Encoding          EncodingType `json:"encoding"`          // Zawgyi 或 Unicode
+	ZawgyiProbability float64      `json:"zawgyiProbability"` // 为 Zawgyi 的概率，不含缅甸文时为 -1
+}
+
+// DetectResponse 缅甸文编码检测响应
+type DetectResponse struct {
+	Results []DetectResult `json:"results"`
+}
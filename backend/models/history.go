@@ -0,0 +1,29 @@
+package models
+
+// GenerateHistory 生成历史记录，每次调用生成接口记录一条
+type GenerateHistory struct {
+	ID              int64                   `json:"id"`
+	UserID          int64                   `json:"userId"`
+	Username        string                  `json:"username"`
+	Template        string                  `json:"template"`                  // 使用的模板（未提供模板时为空）
+	Encoding        EncodingType            `json:"encoding"`                  // 模板字面文本的编码
+	Encodings       map[string]EncodingType `json:"encodings"`                 // 实际使用的各位置编码
+	GenerateMode    GenerateMode            `json:"generateMode"`              // 生成方式
+	Seed            *int64                  `json:"seed,omitempty"`            // 随机生成使用的种子
+	TotalCount      int64                   `json:"totalCount"`                // 组合总数
+	ResultCount     int                     `json:"resultCount"`               // 本次返回的结果数量
+	ExceededCount   int                     `json:"exceededCount"`             // 本次结果中超出限制的数量
+	DurationMs      int64                   `json:"durationMs"`                // 生成耗时（毫秒）
+	TemplateID      int64                   `json:"templateId,omitempty"`      // 使用的已保存模板ID
+	TemplateVersion int                     `json:"templateVersion,omitempty"` // 使用的已保存模板版本号
+	Request         *TemplateRequest        `json:"request,omitempty"`         // 完整的生成参数（仅在查询单条记录时返回）
+	CreatedAt       string                  `json:"createdAt"`
+}
+
+// HistoryListResponse 生成历史列表响应
+type HistoryListResponse struct {
+	History  []GenerateHistory `json:"history"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"pageSize"`
+}
@@ -0,0 +1,51 @@
+package models
+
+// JobStatus 生成任务状态
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"    // 排队中
+	JobRunning   JobStatus = "running"   // 生成中
+	JobSucceeded JobStatus = "succeeded" // 已完成
+	JobFailed    JobStatus = "failed"    // 失败
+	JobCanceled  JobStatus = "canceled"  // 已取消（保留取消前生成的结果）
+)
+
+// GenerateJob 异步生成任务
+type GenerateJob struct {
+	ID            int64            `json:"id"`
+	UserID        int64            `json:"userId"`
+	Status        JobStatus        `json:"status"`
+	TotalCount    int64            `json:"totalCount"`           // 组合总数
+	ExpectedCount int64            `json:"expectedCount"`        // 预计结果数量（设置了约束规则时为上限）
+	ResultCount   int64            `json:"resultCount"`          // 已生成的结果数量
+	ExceededCount int64            `json:"exceededCount"`        // 已生成的结果中超出限制的数量
+	Progress      float64          `json:"progress"`             // 进度（0~1，完成时为1）
+	Seed          *int64           `json:"seed,omitempty"`       // 随机生成使用的种子
+	Error         string           `json:"error,omitempty"`      // 失败原因
+	Request       *TemplateRequest `json:"request,omitempty"`    // 生成参数（仅在查询单个任务时返回）
+	CreatedAt     string           `json:"createdAt"`            // 提交时间
+	StartedAt     string           `json:"startedAt,omitempty"`  // 开始生成时间
+	FinishedAt    string           `json:"finishedAt,omitempty"` // 结束时间
+}
+
+// Finished 任务是否已经结束（完成、失败或取消）
+func (j *GenerateJob) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCanceled
+}
+
+// JobListResponse 生成任务列表响应
+type JobListResponse struct {
+	Jobs  []GenerateJob `json:"jobs"`
+	Total int           `json:"total"`
+}
+
+// JobResultsResponse 生成任务结果分页响应
+type JobResultsResponse struct {
+	JobID      int64             `json:"jobId"`
+	Status     JobStatus         `json:"status"`
+	Results    []GeneratedResult `json:"results"`
+	Offset     int64             `json:"offset"`     // 本页起始位置
+	NextOffset int64             `json:"nextOffset"` // 下一页起始位置
+	HasMore    bool              `json:"hasMore"`    // 是否还有已生成的结果（任务未结束时之后可能还会增加）
+}
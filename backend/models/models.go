@@ -5,8 +5,10 @@ type EncodingType string
 
 const (
 	EncodingASCII   EncodingType = "ASCII"
+	EncodingGSM7    EncodingType = "GSM7"
 	EncodingZawgyi  EncodingType = "Zawgyi"
 	EncodingUnicode EncodingType = "Unicode"
+	EncodingUCS2    EncodingType = "UCS2"
 	EncodingOther   EncodingType = "Other"
 )
 
@@ -16,63 +18,187 @@ type GenerateMode string
 const (
 	GenerateSequential GenerateMode = "sequential"
 	GenerateRandom     GenerateMode = "random"
+	GenerateSample     GenerateMode = "sample" // 随机抽样：不重复地抽取 sampleSize 个组合
+)
+
+// DedupMode 生成结果的去重方式
+type DedupMode string
+
+const (
+	DedupNone       DedupMode = ""           // 不去重
+	DedupExact      DedupMode = "exact"      // 内容完全相同时去重
+	DedupNormalized DedupMode = "normalized" // 按 Unicode NFC 规范化、合并空白并忽略大小写后相同时去重
+)
+
+// SimilarityMethod 近似重复过滤的距离计算方式
+type SimilarityMethod string
+
+const (
+	SimilarityEdit    SimilarityMethod = "edit"    // 编辑距离（按字符计的 Levenshtein 距离）
+	SimilarityJaccard SimilarityMethod = "jaccard" // 字符 n-gram 集合的 Jaccard 距离（1 - 交集/并集）
+)
+
+// SimilarityFilter 近似重复过滤：返回的结果两两之间的距离不小于 MinDistance，
+// 与已返回的结果过于相似的结果会被跳过
+type SimilarityFilter struct {
+	Method      SimilarityMethod `json:"method"`          // 距离计算方式
+	MinDistance float64          `json:"minDistance"`     // 最小距离：edit 为字符数（1~20 的整数），jaccard 为 0~1 之间的小数
+	NGram       int              `json:"ngram,omitempty"` // jaccard 使用的 n-gram 长度（按字符，默认3）
+}
+
+// PositionOrder 随机生成时位置顺序的约束，未列出的位置自由打乱
+type PositionOrder struct {
+	Start  []string   `json:"start,omitempty"`  // 固定在开头的位置，按列出的顺序排列
+	End    []string   `json:"end,omitempty"`    // 固定在结尾的位置，按列出的顺序排列
+	Fixed  []string   `json:"fixed,omitempty"`  // 保持在模板中原有槽位、不参与打乱的位置
+	Groups [][]string `json:"groups,omitempty"` // 必须相邻的位置组，组内按列出的顺序排列，整组参与打乱
+}
+
+// ExportFormat 生成结果的导出格式
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"    // 逗号分隔，默认带 UTF-8 BOM 以便 Excel 正确识别编码
+	ExportXLSX   ExportFormat = "xlsx"   // Excel 工作簿
+	ExportText   ExportFormat = "txt"    // 纯文本，每行一条内容
+	ExportNDJSON ExportFormat = "ndjson" // JSON Lines，每行一个 GeneratedResult
 )
 
 // TemplateRequest 模板生成请求
 type TemplateRequest struct {
-	Template          string                  `json:"template,omitempty"`           // 模板（可选，如果不提供则根据位置自动生成）
-	Encoding          EncodingType            `json:"encoding,omitempty"`           // 兼容旧版本，已废弃，使用 Encodings
-	Encodings         map[string]EncodingType `json:"encodings" binding:"required"` // 位置 -> 编码类型的映射
-	GenerateMode      GenerateMode            `json:"generateMode" binding:"required"`
-	Positions         PositionConfig          `json:"positions" binding:"required"`
+	Template          string                  `json:"template,omitempty"`          // 模板（可选，如果不提供则根据位置自动生成）
+	Encoding          EncodingType            `json:"encoding,omitempty"`          // 兼容旧版本，已废弃，使用 Encodings
+	Encodings         map[string]EncodingType `json:"encodings,omitempty"`         // 位置 -> 编码类型的映射（未指定的位置使用检测到的编码）
+	GenerateMode      GenerateMode            `json:"generateMode"`                // 生成方式（使用已保存的模板时可省略）
+	Positions         PositionConfig          `json:"positions"`                   // 位置 -> 候选值的映射
 	SpeechGroups      map[string]string       `json:"speechGroups,omitempty"`      // 位置 -> 话术组名称或ID的映射
 	SelectedPositions []string                `json:"selectedPositions,omitempty"` // 选择的位置（如 ["a", "b", "c", "d"]）
 	MaxChars          int                     `json:"maxChars,omitempty"`          // 最大字符数限制（默认70）
+	MaxSegments       int                     `json:"maxSegments,omitempty"`       // 最大短信分段数（0表示不限制）
+	OutputEncoding    EncodingType            `json:"outputEncoding,omitempty"`    // 缅甸文输出编码（Zawgyi 或 Unicode，为空则不转换）
+	Offset            int64                   `json:"offset,omitempty"`            // 分页起始位置（从0开始）
+	Limit             int                     `json:"limit,omitempty"`             // 本页最多返回条数（0表示默认：生成接口为服务端上限，导出和异步任务为全部）
+	Seed              *int64                  `json:"seed,omitempty"`              // 随机种子（可选，随机生成时相同种子得到相同结果）
+	SampleSize        int64                   `json:"sampleSize,omitempty"`        // 抽样数量（抽样生成时必填，超过组合总数时取组合总数）
+	Weights           map[string][]float64    `json:"weights,omitempty"`           // 位置 -> 各值权重（与 positions 中的值一一对应，未指定时话术组使用话术的权重，其余等权）
+	Rules             []ConstraintRule        `json:"rules,omitempty"`             // 位置之间的约束规则，不满足规则的组合会被跳过
+	TemplateID        int64                   `json:"templateId,omitempty"`        // 已保存模板的ID（可选，以模板保存的参数为基础，并使用其约束规则）
+	TemplateVersion   int                     `json:"templateVersion,omitempty"`   // 使用已保存模板的指定版本（可选，默认使用最新版本）
+	AutoFit           bool                    `json:"autoFit,omitempty"`           // 自动适配：超出限制的结果改用同一位置中更短的值，或省略可选位置
+	OptionalPositions []string                `json:"optionalPositions,omitempty"` // 自动适配时可以省略的位置
+	Dedup             DedupMode               `json:"dedup,omitempty"`             // 去重方式（默认不去重），保留第一次出现的结果
+	Similarity        *SimilarityFilter       `json:"similarity,omitempty"`        // 近似重复过滤（可选），保留第一次出现的结果
+	PositionOrder     *PositionOrder          `json:"positionOrder,omitempty"`     // 随机生成时位置顺序的约束（可选，默认全部打乱）
 }
 
-// PositionConfig 位置配置
-type PositionConfig struct {
-	A []string `json:"a"`
-	B []string `json:"b"`
-	C []string `json:"c"`
-	D []string `json:"d"`
-	E []string `json:"e,omitempty"`
-	F []string `json:"f,omitempty"`
-	G []string `json:"g,omitempty"`
-	H []string `json:"h,omitempty"`
-	I []string `json:"i,omitempty"`
-	J []string `json:"j,omitempty"`
-}
+// PositionConfig 位置配置，键为位置标识（如 a、b 或自定义的位置名称），值为该位置的候选值
+type PositionConfig map[string][]string
 
 // GeneratedResult 生成结果
 type GeneratedResult struct {
-	Content       string `json:"content"`
-	CharCount     int    `json:"charCount"`
-	IsExceeded    bool   `json:"isExceeded"`
-	ExceededChars int    `json:"exceededChars"`
+	Content           string          `json:"content"`
+	CharCount         int             `json:"charCount"`
+	IsExceeded        bool            `json:"isExceeded"`
+	ExceededChars     int             `json:"exceededChars"`
+	UnsupportedChars  []string        `json:"unsupportedChars,omitempty"` // GSM-7 位置中无法表示的字符
+	SMSEncoding       EncodingType    `json:"smsEncoding"`                // 实际发送编码（GSM7 或 UCS2）
+	Segments          int             `json:"segments"`                   // 短信分段数
+	SegmentBoundaries []SMSSegment    `json:"segmentBoundaries"`          // 每段的边界
+	RemainingUnits    int             `json:"remainingUnits"`             // 最后一段剩余可用单位数
+	UCS2Chars         []string        `json:"ucs2Chars,omitempty"`        // 导致整条短信改用 UCS-2 发送的字符
+	Adjustments       []FitAdjustment `json:"adjustments,omitempty"`      // 自动适配所做的调整（未调整时为空）
+	Overflow          *OverflowMark   `json:"overflow,omitempty"`         // 超出部分的位置（未超出时为空）
+}
+
+// OverflowMark 超出部分的标识：从起始偏移到内容末尾均为超出部分
+// 字符数超出时，起始位置为累计字符数（按各位置的编码计算）首次超过最大字符数的字符；
+// 分段数超出时为最后一个允许的分段的结束位置，两者都超出时取较前者
+type OverflowMark struct {
+	ByteOffset int                `json:"byteOffset"` // 超出部分在内容中的起始字节偏移（UTF-8）
+	RuneOffset int                `json:"runeOffset"` // 超出部分在内容中的起始字符偏移（按 Unicode 码点计）
+	Positions  []OverflowPosition `json:"positions"`  // 与超出部分重叠的位置，按在内容中出现的顺序排列
+}
+
+// OverflowPosition 与超出部分重叠的位置
+type OverflowPosition struct {
+	Position  string `json:"position"`  // 位置标识
+	Start     int    `json:"start"`     // 该位置的值在内容中的起始字符偏移（包含）
+	End       int    `json:"end"`       // 结束字符偏移（不包含）
+	Straddles bool   `json:"straddles"` // 是否跨越超出边界（部分在限制内，部分超出）
+}
+
+// FitAction 自动适配的调整方式
+type FitAction string
+
+const (
+	FitSubstituted FitAction = "substituted" // 替换为同一位置中更短的值
+	FitDropped     FitAction = "dropped"     // 省略可选位置
+)
+
+// FitAdjustment 自动适配对一个位置所做的调整
+type FitAdjustment struct {
+	Position string    `json:"position"`        // 位置标识
+	Action   FitAction `json:"action"`          // 调整方式
+	Original string    `json:"original"`        // 原来的值
+	Value    string    `json:"value,omitempty"` // 替换后的值（省略时为空）
+}
+
+// SMSSegment 短信分段
+type SMSSegment struct {
+	Start int `json:"start"` // 起始字符位置（按字符计，包含）
+	End   int `json:"end"`   // 结束字符位置（按字符计，不包含）
+	Units int `json:"units"` // 本段占用单位数（GSM-7 为 septet，UCS-2 为 UTF-16 单元）
 }
 
 // GenerateResponse 生成响应
 type GenerateResponse struct {
 	Results       []GeneratedResult `json:"results"`
-	TotalCount    int               `json:"totalCount"`
-	ExceededCount int               `json:"exceededCount"`
+	TotalCount    int64             `json:"totalCount"`           // 组合总数（由各位置值数量相乘得到）
+	ExceededCount int               `json:"exceededCount"`        // 本页中超出限制的数量
+	PrunedCount   int64             `json:"prunedCount"`          // 本页遍历范围内被约束规则剪除的组合数量
+	AdjustedCount int               `json:"adjustedCount"`        // 本页中经自动适配调整后不再超出的数量
+	DedupCount    int64             `json:"dedupCount"`           // 本页遍历范围内因内容重复被去掉的结果数量
+	SimilarCount  int64             `json:"similarCount"`         // 本页遍历范围内因与已返回的结果过于相似被去掉的结果数量
+	Offset        int64             `json:"offset"`               // 本页起始位置
+	NextOffset    int64             `json:"nextOffset"`           // 下一页起始位置
+	HasMore       bool              `json:"hasMore"`              // 是否还有下一页
+	SampleSize    int64             `json:"sampleSize,omitempty"` // 抽样生成时实际抽取的组合数量
+	Seed          *int64            `json:"seed,omitempty"`       // 随机生成使用的种子，传回请求即可复现同一批结果
+
+	Encodings    map[string]EncodingType       `json:"encodings"`              // 实际使用的各位置编码（含自动检测的默认编码）
+	Distribution map[string][]ValueProbability `json:"distribution,omitempty"` // 加权随机/抽样时，设置了权重的位置中各值的期望出现概率
+
+	TemplateID      int64 `json:"templateId,omitempty"`      // 使用的已保存模板ID
+	TemplateVersion int   `json:"templateVersion,omitempty"` // 使用的已保存模板版本号
+}
+
+// ValueProbability 位置值的权重及期望出现概率
+type ValueProbability struct {
+	Value       string  `json:"value"`
+	Weight      float64 `json:"weight"`
+	Probability float64 `json:"probability"`
 }
 
 // PositionValue 位置值配置
 type PositionValue struct {
-	ID       int64  `json:"id"`
-	Position string `json:"position" binding:"required"` // a, b, c, d
-	Value    string `json:"value" binding:"required"`
+	ID       int64        `json:"id"`
+	Position string       `json:"position" binding:"required"` // 位置标识
+	Value    string       `json:"value" binding:"required"`
+	Encoding EncodingType `json:"encoding"` // 检测到的编码
+	Weight   float64      `json:"weight"`   // 随机生成时的权重
 }
 
 // PositionValueRequest 位置值请求
 type PositionValueRequest struct {
-	Position string `json:"position" binding:"required"`
-	Value    string `json:"value" binding:"required"`
+	Position string   `json:"position" binding:"required"`
+	Value    string   `json:"value" binding:"required"`
+	Weight   *float64 `json:"weight"` // 可选，默认为1
 }
 
 // PositionValueListResponse 位置值列表响应
 type PositionValueListResponse struct {
-	Positions map[string][]string `json:"positions"`
+	Positions   map[string][]string       `json:"positions"`
+	Encodings   map[string][]EncodingType `json:"encodings"`   // 每个值检测到的编码，与 positions 一一对应
+	Weights     map[string][]float64      `json:"weights"`     // 每个值的权重，与 positions 一一对应
+	Definitions []Position                `json:"definitions"` // 位置定义，按排序顺序排列
 }
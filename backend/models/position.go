@@ -0,0 +1,29 @@
+package models
+
+// Position 位置定义
+type Position struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`        // 位置标识，模板中以 (@name) 引用
+	Label       string `json:"label"`       // 显示名称
+	Description string `json:"description"` // 描述
+	SortOrder   int    `json:"sortOrder"`   // 排序顺序
+	ValueCount  int    `json:"valueCount"`  // 位置值数量
+	CreatedAt   string `json:"createdAt,omitempty"`
+	UpdatedAt   string `json:"updatedAt,omitempty"`
+}
+
+// PositionRequest 创建位置请求
+type PositionRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	SortOrder   int    `json:"sortOrder"`
+}
+
+// PositionUpdateRequest 更新位置请求（字段为空时保持不变）
+type PositionUpdateRequest struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	SortOrder   *int   `json:"sortOrder"`
+}
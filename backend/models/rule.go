@@ -0,0 +1,31 @@
+package models
+
+// RuleType 约束规则类型
+type RuleType string
+
+const (
+	RuleExcludes RuleType = "excludes" // 位置 position 取值 value 时，位置 targetPosition 不能取值 targetValue
+	RuleRequires RuleType = "requires" // 位置 position 取值 value 时，位置 targetPosition 必须取值 targetValue（同一条件的多条规则取并集）
+)
+
+// ConstraintRule 位置之间的约束规则
+type ConstraintRule struct {
+	ID             int64    `json:"id,omitempty"`
+	TemplateID     int64    `json:"templateId,omitempty"`
+	Position       string   `json:"position" binding:"required"`
+	Value          string   `json:"value" binding:"required"`
+	Type           RuleType `json:"type" binding:"required"`
+	TargetPosition string   `json:"targetPosition" binding:"required"`
+	TargetValue    string   `json:"targetValue" binding:"required"`
+}
+
+// ConstraintRuleListRequest 设置模板约束规则请求（整体替换）
+type ConstraintRuleListRequest struct {
+	Rules []ConstraintRule `json:"rules" binding:"dive"`
+}
+
+// ConstraintRuleListResponse 模板约束规则列表响应
+type ConstraintRuleListResponse struct {
+	TemplateID int64            `json:"templateId"`
+	Rules      []ConstraintRule `json:"rules"`
+}
@@ -2,26 +2,30 @@ package models
 
 // SpeechGroup 话术组
 type SpeechGroup struct {
-	ID          int64    `json:"id"`
-	Name        string   `json:"name" binding:"required"`        // 话术组名称
-	Description string   `json:"description"`                    // 描述
-	Speeches    []string `json:"speeches" binding:"required"`    // 话术列表
-	CreatedAt   string   `json:"createdAt,omitempty"`
-	UpdatedAt   string   `json:"updatedAt,omitempty"`
+	ID          int64          `json:"id"`
+	Name        string         `json:"name" binding:"required"`     // 话术组名称
+	Description string         `json:"description"`                 // 描述
+	Speeches    []string       `json:"speeches" binding:"required"` // 话术列表
+	Encodings   []EncodingType `json:"encodings,omitempty"`         // 每条话术检测到的编码，与 speeches 一一对应
+	Weights     []float64      `json:"weights,omitempty"`           // 每条话术的权重，与 speeches 一一对应
+	CreatedAt   string         `json:"createdAt,omitempty"`
+	UpdatedAt   string         `json:"updatedAt,omitempty"`
 }
 
 // SpeechGroupRequest 话术组请求
 type SpeechGroupRequest struct {
-	Name        string   `json:"name" binding:"required"`
-	Description string   `json:"description"`
-	Speeches    []string `json:"speeches" binding:"required,min=1"`
+	Name        string    `json:"name" binding:"required"`
+	Description string    `json:"description"`
+	Speeches    []string  `json:"speeches" binding:"required,min=1"`
+	Weights     []float64 `json:"weights"` // 可选，与 speeches 一一对应，未提供时均为1
 }
 
 // SpeechGroupUpdateRequest 话术组更新请求
 type SpeechGroupUpdateRequest struct {
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Speeches    []string `json:"speeches"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Speeches    []string  `json:"speeches"`
+	Weights     []float64 `json:"weights"` // 可选，与 speeches 一一对应，未提供时均为1
 }
 
 // SpeechGroupListResponse 话术组列表响应
@@ -29,4 +33,3 @@ type SpeechGroupListResponse struct {
 	Groups []SpeechGroup `json:"groups"`
 	Total  int           `json:"total"`
 }
-
@@ -0,0 +1,70 @@
+package models
+
+// SavedTemplate 已保存的模板
+type SavedTemplate struct {
+	ID        int64           `json:"id"`
+	Name      string          `json:"name"`     // 模板名称
+	Template  string          `json:"template"` // 模板内容（与 config.template 相同）
+	Encoding  EncodingType    `json:"encoding"` // 模板编码（与 config.encoding 相同，未指定时为 Unicode）
+	Config    TemplateRequest `json:"config"`   // 保存的生成参数
+	CreatedAt string          `json:"createdAt,omitempty"`
+	UpdatedAt string          `json:"updatedAt,omitempty"`
+}
+
+// SavedTemplateRequest 保存模板请求
+type SavedTemplateRequest struct {
+	Name   string          `json:"name" binding:"required,max=100"`
+	Config TemplateRequest `json:"config"` // 生成参数，格式同生成请求（分页参数不保存）
+}
+
+// SavedTemplateUpdateRequest 更新模板请求
+type SavedTemplateUpdateRequest struct {
+	Name   string           `json:"name" binding:"max=100"`
+	Config *TemplateRequest `json:"config"` // 提供时整体替换保存的生成参数
+}
+
+// SavedTemplateListResponse 模板列表响应
+type SavedTemplateListResponse struct {
+	Templates []SavedTemplate `json:"templates"`
+	Total     int             `json:"total"`
+}
+
+// TemplateVersion 模板的历史版本（不可修改），每次保存、更新、修改规则或恢复都会产生一个新版本
+type TemplateVersion struct {
+	ID         int64            `json:"id"`
+	TemplateID int64            `json:"templateId"`
+	Version    int              `json:"version"` // 版本号（从1开始递增）
+	Name       string           `json:"name"`
+	Template   string           `json:"template"`
+	Encoding   EncodingType     `json:"encoding"`
+	Config     TemplateRequest  `json:"config"`    // 该版本的生成参数（话术组绑定、编码等）
+	Rules      []ConstraintRule `json:"rules"`     // 该版本的约束规则
+	AuthorID   int64            `json:"authorId"`  // 修改人ID
+	Author     string           `json:"author"`    // 修改人用户名
+	Comment    string           `json:"comment"`   // 修改说明（如“创建”“更新”“修改规则”“从版本 2 恢复”）
+	CreatedAt  string           `json:"createdAt"` // 修改时间
+}
+
+// TemplateVersionListResponse 模板版本列表响应（按版本号从新到旧排列）
+type TemplateVersionListResponse struct {
+	TemplateID int64             `json:"templateId"`
+	Versions   []TemplateVersion `json:"versions"`
+	Total      int               `json:"total"`
+}
+
+// TemplateFieldChange 两个版本之间一个字段的变化
+type TemplateFieldChange struct {
+	Field string      `json:"field"` // 字段名，生成参数中的字段以 config. 开头（如 config.encodings）
+	From  interface{} `json:"from"`  // 旧值（不存在时为 null）
+	To    interface{} `json:"to"`    // 新值（不存在时为 null）
+}
+
+// TemplateVersionDiff 两个版本之间的差异
+type TemplateVersionDiff struct {
+	TemplateID   int64                 `json:"templateId"`
+	From         int                   `json:"from"`
+	To           int                   `json:"to"`
+	Changes      []TemplateFieldChange `json:"changes"`      // 名称、模板内容、编码和生成参数的变化
+	RulesAdded   []ConstraintRule      `json:"rulesAdded"`   // 新版本中新增的规则
+	RulesRemoved []ConstraintRule      `json:"rulesRemoved"` // 新版本中删除的规则
+}
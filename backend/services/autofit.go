@@ -0,0 +1,198 @@
+package services
+
+import (
+	"sayhi/backend/models"
+	"sayhi/backend/utils"
+	"sort"
+)
+
+// autoFitPlan 自动适配参数：各位置值的字符数及按字符数排列的候选顺序
+type autoFitPlan struct {
+	optional []bool  // 每个槽位是否可以省略
+	lengths  [][]int // 每个槽位各值的字符数（未乘以在模板中出现的次数，随机生成时值所在的槽位会变化）
+	shortest [][]int // 每个槽位的值下标，按字符数从小到大排列（字符数相同时保持原顺序）
+}
+
+// newAutoFitPlan 计算各位置值的字符数，optional 中不属于本次生成的位置会被忽略
+func newAutoFitPlan(positionKeys []string, positionValues [][]string, encodings map[string]models.EncodingType, optional []string) *autoFitPlan {
+	optionalKeys := make(map[string]bool, len(optional))
+	for _, key := range optional {
+		optionalKeys[key] = true
+	}
+
+	fit := &autoFitPlan{
+		optional: make([]bool, len(positionKeys)),
+		lengths:  make([][]int, len(positionKeys)),
+		shortest: make([][]int, len(positionKeys)),
+	}
+	for i, key := range positionKeys {
+		fit.optional[i] = optionalKeys[key]
+
+		encoding := models.EncodingUnicode
+		if enc, exists := encodings[key]; exists {
+			encoding = enc
+		}
+		lengths := make([]int, len(positionValues[i]))
+		order := make([]int, len(positionValues[i]))
+		for j, value := range positionValues[i] {
+			lengths[j] = utils.CountChars(value, encoding)
+			order[j] = j
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return lengths[order[a]] < lengths[order[b]]
+		})
+		fit.lengths[i] = lengths
+		fit.shortest[i] = order
+	}
+	return fit
+}
+
+// autoFit 调整超出限制的组合，使其不再超出字符数和分段数限制
+// 先按可缩短的字符数从多到少依次处理各位置：改用该位置中能使结果不超出的最长的较短值，
+// 都不能时改用最短的值并继续处理下一个位置；仍然超出时依次多省略一个可选位置（从字符数多的开始），
+// 再从原组合开始重新缩短其余位置，因此只在替换无法适配时才省略位置。
+// 调整后的组合同样需要满足约束规则；无法适配时 ok 为 false，调用方保留原组合。
+// perm 为随机生成时组合内位置的排列（perm[目标槽位] = 原位置，nil 表示保持模板顺序），
+// 字符数和分段数按排列后实际渲染的内容计算，与最终生成的结果一致；返回的 values 仍按原位置顺序排列
+func (tg *TemplateGenerator) autoFit(plan *generationPlan, index int64, perm []int) (values []string, adjustments []models.FitAdjustment, ok bool) {
+	fit := plan.autoFit
+	original := plan.space.IndicesAt(index, make([]int, plan.space.Size()))
+	current := append([]int(nil), original...)
+	values = make([]string, len(current))
+
+	// 每个位置的值按其所在槽位在模板中出现的次数计算字符数
+	weight := make([]int, len(current))
+	arranged := values
+	if perm == nil {
+		copy(weight, plan.occurrences)
+	} else {
+		for target, source := range perm {
+			weight[source] = plan.occurrences[target]
+		}
+		arranged = make([]string, len(values))
+	}
+	cost := func(i, j int) int {
+		return fit.lengths[i][j] * weight[i]
+	}
+
+	fill := func() {
+		for i, j := range current {
+			values[i] = ""
+			if j >= 0 {
+				values[i] = plan.space.values[i][j]
+			}
+		}
+	}
+	fits := func() bool {
+		charCount := plan.literalChars
+		for i, j := range current {
+			if j >= 0 {
+				charCount += cost(i, j)
+			}
+		}
+		if utils.IsExceeded(charCount, plan.maxChars) {
+			return false
+		}
+		if plan.maxSegments <= 0 {
+			return true
+		}
+		fill()
+		if perm != nil {
+			for target, source := range perm {
+				arranged[target] = values[source]
+			}
+		}
+		seg := utils.SplitSegments(utils.RenderSegments(plan.segments, arranged))
+		return len(seg.Segments) <= plan.maxSegments
+	}
+	finish := func() ([]string, []models.FitAdjustment, bool) {
+		fill()
+		for i, j := range current {
+			if j == original[i] {
+				continue
+			}
+			adjustment := models.FitAdjustment{
+				Position: plan.positionKeys[i],
+				Action:   models.FitSubstituted,
+				Original: plan.space.values[i][original[i]],
+				Value:    values[i],
+			}
+			if j < 0 {
+				adjustment.Action = models.FitDropped
+			}
+			adjustments = append(adjustments, adjustment)
+		}
+		return values, adjustments, true
+	}
+
+	// 依次缩短各位置的值，能使结果不超出时返回 true
+	slots := make([]int, len(current))
+	for i := range slots {
+		slots[i] = i
+	}
+	saving := func(i int) int {
+		if current[i] < 0 {
+			return 0
+		}
+		return cost(i, current[i]) - cost(i, fit.shortest[i][0])
+	}
+	shorten := func() bool {
+		sort.SliceStable(slots, func(a, b int) bool {
+			return saving(slots[a]) > saving(slots[b])
+		})
+		for _, slot := range slots {
+			if saving(slot) <= 0 {
+				continue
+			}
+			previous := current[slot]
+			shortestAllowed := -1
+			// 候选值按字符数从小到大排列，倒序遍历即从最长的较短值开始尝试
+			order := fit.shortest[slot]
+			for k := len(order) - 1; k >= 0; k-- {
+				candidate := order[k]
+				if fit.lengths[slot][candidate] >= fit.lengths[slot][previous] {
+					continue
+				}
+				current[slot] = candidate
+				if !plan.constraints.AllowsIndices(current) {
+					continue
+				}
+				if fits() {
+					return true
+				}
+				shortestAllowed = candidate
+			}
+			current[slot] = previous
+			if shortestAllowed >= 0 {
+				current[slot] = shortestAllowed
+			}
+		}
+		return false
+	}
+
+	// 可选位置按原值的字符数从多到少排列，每次多省略一个，并从原组合开始重新缩短其余位置
+	var optional []int
+	for i, isOptional := range fit.optional {
+		if isOptional {
+			optional = append(optional, i)
+		}
+	}
+	sort.SliceStable(optional, func(a, b int) bool {
+		return cost(optional[a], original[optional[a]]) > cost(optional[b], original[optional[b]])
+	})
+
+	for dropped := 0; dropped <= len(optional); dropped++ {
+		copy(current, original)
+		for _, slot := range optional[:dropped] {
+			current[slot] = -1
+		}
+		if dropped > 0 && plan.constraints.AllowsIndices(current) && fits() {
+			return finish()
+		}
+		if shorten() {
+			return finish()
+		}
+	}
+
+	return nil, nil, false
+}
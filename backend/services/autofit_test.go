@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"sayhi/backend/models"
+	"testing"
+)
+
+// 随机生成时位置会换到出现次数不同的槽位，自动适配需要按实际排列判断是否超出
+func TestAutoFitUsesRenderedArrangement(t *testing.T) {
+	tg := NewTemplateGenerator(NewSpeechService())
+	for seed := int64(0); seed < 20; seed++ {
+		seed := seed
+		req := &models.TemplateRequest{
+			Template:     "(@x)(@x)(@x) (a)",
+			GenerateMode: models.GenerateRandom,
+			Positions: models.PositionConfig{
+				"x": {"aaaa", "b"},
+				"a": {"cccccccccc", "dd", "e"},
+			},
+			MaxChars: 14,
+			AutoFit:  true,
+			Seed:     &seed,
+		}
+		resp, err := tg.Generate(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		adjusted := 0
+		for _, result := range resp.Results {
+			if len(result.Adjustments) == 0 {
+				continue
+			}
+			if result.IsExceeded {
+				t.Errorf("seed=%d: 调整后的结果 %q 仍然超出（%d 字符）", seed, result.Content, result.CharCount)
+				continue
+			}
+			adjusted++
+		}
+		if resp.AdjustedCount != adjusted {
+			t.Errorf("seed=%d: adjustedCount=%d，调整后不再超出的结果有 %d 条", seed, resp.AdjustedCount, adjusted)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// CombinationSpace 组合空间
+// 将所有位置值的笛卡尔积看作一个混合进制数空间：第 i 位的进制为位置 i 的值个数，
+// 每个组合对应空间中唯一的一个下标，因此无需在内存中构建全部组合即可按下标取出任意组合
+type CombinationSpace struct {
+	values [][]string
+	radix  []int64
+	total  int64
+}
+
+// NewCombinationSpace 创建组合空间
+func NewCombinationSpace(values [][]string) (*CombinationSpace, error) {
+	cs := &CombinationSpace{
+		values: values,
+		radix:  make([]int64, len(values)),
+	}
+	if len(values) == 0 {
+		return cs, nil
+	}
+
+	total := int64(1)
+	for i, vals := range values {
+		size := int64(len(vals))
+		if size == 0 {
+			// 任意位置没有值时组合数为0
+			total = 0
+		} else if total > 0 && total > math.MaxInt64/size {
+			return nil, fmt.Errorf("组合数量过大，超出可处理范围")
+		} else {
+			total *= size
+		}
+		cs.radix[i] = size
+	}
+	cs.total = total
+
+	return cs, nil
+}
+
+// Total 组合总数
+func (cs *CombinationSpace) Total() int64 {
+	return cs.total
+}
+
+// Size 位置数量
+func (cs *CombinationSpace) Size() int {
+	return len(cs.values)
+}
+
+// IndicesAt 将组合下标转换为各位置的值下标，结果写入 dst
+// 最后一个位置变化最快，与原先递归生成的顺序一致
+func (cs *CombinationSpace) IndicesAt(index int64, dst []int) []int {
+	if cap(dst) < len(cs.radix) {
+		dst = make([]int, len(cs.radix))
+	}
+	dst = dst[:len(cs.radix)]
+	for i := len(cs.radix) - 1; i >= 0; i-- {
+		dst[i] = int(index % cs.radix[i])
+		index /= cs.radix[i]
+	}
+	return dst
+}
+
+// IndexOf 将各位置的值下标转换为组合下标，是 IndicesAt 的逆运算
+func (cs *CombinationSpace) IndexOf(indices []int) int64 {
+	var index int64
+	for i, radix := range cs.radix {
+		index = index*radix + int64(indices[i])
+	}
+	return index
+}
+
+// At 根据组合下标取出组合，结果写入 dst
+func (cs *CombinationSpace) At(index int64, dst []string) []string {
+	if cap(dst) < len(cs.values) {
+		dst = make([]string, len(cs.values))
+	}
+	dst = dst[:len(cs.values)]
+	for i := len(cs.radix) - 1; i >= 0; i-- {
+		dst[i] = cs.values[i][index%cs.radix[i]]
+		index /= cs.radix[i]
+	}
+	return dst
+}
+
+// IndexOrder 组合下标的遍历顺序，将第 n 次迭代映射为组合下标
+type IndexOrder func(n int64) int64
+
+// SequentialOrder 顺序遍历
+func SequentialOrder(n int64) int64 {
+	return n
+}
+
+// NewFeistelOrder 创建基于 Feistel 网络的伪随机遍历顺序
+// 在 [0, 4^h) 上做4轮平衡 Feistel 置换，结果超出 total 时继续置换（cycle walking），
+// 得到 [0, total) 上的双射；计算第 n 个下标的代价与 total 无关，也不需要额外内存
+func NewFeistelOrder(total int64, seed int64) IndexOrder {
+	if total <= 1 {
+		return SequentialOrder
+	}
+
+	half := (bits.Len64(uint64(total-1)) + 1) / 2
+	mask := uint64(1)<<half - 1
+
+	var keys [4]uint64
+	state := uint64(seed)
+	for i := range keys {
+		state, keys[i] = splitMix64(state)
+	}
+
+	permute := func(x uint64) uint64 {
+		left, right := x>>half, x&mask
+		for _, key := range keys {
+			_, f := splitMix64(right ^ key)
+			left, right = right, left^(f&mask)
+		}
+		return left<<half | right
+	}
+
+	return func(n int64) int64 {
+		x := uint64(n)
+		for {
+			x = permute(x)
+			if x < uint64(total) {
+				return int64(x)
+			}
+		}
+	}
+}
+
+// ShuffleAt 按种子和组合下标确定性地打乱 n 个元素（Fisher-Yates）
+// 同一种子、同一组合下标总是得到相同的排列，与生成顺序和分页位置无关
+func ShuffleAt(seed, index int64, n int, swap func(i, j int)) {
+	state := uint64(seed) ^ uint64(index)*0x9e3779b97f4a7c15
+	for i := n - 1; i > 0; i-- {
+		var r uint64
+		state, r = splitMix64(state)
+		swap(i, int(r%uint64(i+1)))
+	}
+}
+
+// splitMix64 SplitMix64 伪随机数生成器，返回新状态和输出
+func splitMix64(state uint64) (uint64, uint64) {
+	state += 0x9e3779b97f4a7c15
+	z := state
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return state, z ^ z>>31
+}
+
+// CombinationIterator 组合迭代器，每次产出一个组合
+type CombinationIterator struct {
+	space   *CombinationSpace
+	order   IndexOrder
+	sampler *WeightedSampler // 不为 nil 时按权重抽取组合下标，忽略 order
+	next    int64
+	combo   []string
+}
+
+// NewCombinationIterator 创建组合迭代器
+func NewCombinationIterator(space *CombinationSpace, order IndexOrder) *CombinationIterator {
+	if order == nil {
+		order = SequentialOrder
+	}
+	return &CombinationIterator{
+		space: space,
+		order: order,
+		combo: make([]string, space.Size()),
+	}
+}
+
+// NewWeightedIterator 创建按权重抽取组合的迭代器
+func NewWeightedIterator(space *CombinationSpace, sampler *WeightedSampler) *CombinationIterator {
+	return &CombinationIterator{
+		space:   space,
+		sampler: sampler,
+		combo:   make([]string, space.Size()),
+	}
+}
+
+// Next 返回下一个组合及其组合下标，迭代结束时 ok 为 false
+// 返回的切片在下一次调用 Next 时会被复用，如需保留请自行复制
+func (it *CombinationIterator) Next() (combo []string, index int64, ok bool) {
+	if it.next >= it.space.Total() {
+		return nil, 0, false
+	}
+	if it.sampler != nil {
+		if index, ok = it.sampler.Next(); !ok {
+			return nil, 0, false
+		}
+	} else {
+		index = it.order(it.next)
+	}
+	it.next++
+	it.combo = it.space.At(index, it.combo)
+	return it.combo, index, true
+}
+
+// SkipTo 跳到第 n 次迭代，之后的 Next 从该位置继续
+// 加权抽取的结果依赖此前已抽出的组合，只能向前跳，且需要依次重放前面的抽取
+func (it *CombinationIterator) SkipTo(n int64) {
+	if n < 0 {
+		n = 0
+	}
+	if it.sampler != nil {
+		for it.next < n {
+			if _, ok := it.sampler.Next(); !ok {
+				break
+			}
+			it.next++
+		}
+		return
+	}
+	it.next = n
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestNewCombinationSpace(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  [][]string
+		total   int64
+		wantErr bool
+	}{
+		{name: "没有位置", values: nil, total: 0},
+		{name: "单个位置", values: [][]string{{"a", "b", "c"}}, total: 3},
+		{name: "多个位置", values: [][]string{{"a", "b"}, {"1", "2", "3"}, {"x"}}, total: 6},
+		{name: "某个位置没有值", values: [][]string{{"a", "b"}, {}}, total: 0},
+		{name: "组合数溢出", values: repeatValues(64, 2), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := NewCombinationSpace(tt.values)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("期望返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cs.Total() != tt.total {
+				t.Errorf("Total() = %d，期望 %d", cs.Total(), tt.total)
+			}
+		})
+	}
+}
+
+// repeatValues 返回 n 个位置，每个位置有 size 个值
+func repeatValues(n, size int) [][]string {
+	values := make([][]string, n)
+	for i := range values {
+		for j := 0; j < size; j++ {
+			values[i] = append(values[i], strconv.Itoa(j))
+		}
+	}
+	return values
+}
+
+func TestCombinationSpaceIndexing(t *testing.T) {
+	cs, err := NewCombinationSpace([][]string{{"a", "b"}, {"1", "2", "3"}, {"x", "y"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 最后一个位置变化最快
+	want := [][]string{
+		{"a", "1", "x"}, {"a", "1", "y"}, {"a", "2", "x"}, {"a", "2", "y"}, {"a", "3", "x"}, {"a", "3", "y"},
+		{"b", "1", "x"}, {"b", "1", "y"}, {"b", "2", "x"}, {"b", "2", "y"}, {"b", "3", "x"}, {"b", "3", "y"},
+	}
+	for index := int64(0); index < cs.Total(); index++ {
+		if got := cs.At(index, nil); !reflect.DeepEqual(got, want[index]) {
+			t.Errorf("At(%d) = %v，期望 %v", index, got, want[index])
+		}
+		indices := cs.IndicesAt(index, nil)
+		if got := cs.IndexOf(indices); got != index {
+			t.Errorf("IndexOf(IndicesAt(%d)) = %d", index, got)
+		}
+	}
+}
+
+func TestFeistelOrderIsBijection(t *testing.T) {
+	totals := []int64{1, 2, 3, 4, 5, 7, 16, 17, 100, 255, 256, 257, 1000, 4097}
+	for _, total := range totals {
+		for _, seed := range []int64{0, 1, 42, -7, math.MaxInt64} {
+			order := NewFeistelOrder(total, seed)
+			seen := make([]bool, total)
+			for n := int64(0); n < total; n++ {
+				index := order(n)
+				if index < 0 || index >= total {
+					t.Fatalf("total=%d seed=%d: 第 %d 个下标 %d 超出范围", total, seed, n, index)
+				}
+				if seen[index] {
+					t.Fatalf("total=%d seed=%d: 下标 %d 重复出现", total, seed, index)
+				}
+				seen[index] = true
+			}
+		}
+	}
+}
+
+func TestFeistelOrderDependsOnSeed(t *testing.T) {
+	const total = 1000
+	a, b := NewFeistelOrder(total, 1), NewFeistelOrder(total, 2)
+	same, sequential := 0, 0
+	for n := int64(0); n < total; n++ {
+		if a(n) == b(n) {
+			same++
+		}
+		if a(n) == n {
+			sequential++
+		}
+	}
+	if same > total/10 {
+		t.Errorf("不同种子有 %d 个位置的下标相同", same)
+	}
+	if sequential > total/10 {
+		t.Errorf("有 %d 个位置的下标与顺序遍历相同", sequential)
+	}
+
+	again := NewFeistelOrder(total, 1)
+	for n := int64(0); n < total; n++ {
+		if a(n) != again(n) {
+			t.Fatalf("相同种子第 %d 个下标不同", n)
+		}
+	}
+}
+
+func TestShuffleAtIsDeterministicPermutation(t *testing.T) {
+	for n := 0; n <= 8; n++ {
+		for index := int64(0); index < 20; index++ {
+			shuffle := func() []int {
+				perm := make([]int, n)
+				for i := range perm {
+					perm[i] = i
+				}
+				ShuffleAt(99, index, n, func(i, j int) { perm[i], perm[j] = perm[j], perm[i] })
+				return perm
+			}
+			first := shuffle()
+			if !reflect.DeepEqual(first, shuffle()) {
+				t.Fatalf("n=%d index=%d: 同一种子和下标得到不同的排列", n, index)
+			}
+			seen := make([]bool, n)
+			for _, v := range first {
+				if seen[v] {
+					t.Fatalf("n=%d index=%d: %v 不是排列", n, index, first)
+				}
+				seen[v] = true
+			}
+		}
+	}
+}
+
+func TestCombinationIteratorSkipTo(t *testing.T) {
+	cs, err := NewCombinationSpace(repeatValues(3, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := NewFeistelOrder(cs.Total(), 5)
+
+	var all []int64
+	it := NewCombinationIterator(cs, order)
+	for {
+		_, index, ok := it.Next()
+		if !ok {
+			break
+		}
+		all = append(all, index)
+	}
+	if int64(len(all)) != cs.Total() {
+		t.Fatalf("遍历得到 %d 个组合，期望 %d 个", len(all), cs.Total())
+	}
+
+	for _, start := range []int64{0, 1, 17, 63, 64} {
+		it := NewCombinationIterator(cs, order)
+		it.SkipTo(start)
+		var rest []int64
+		for {
+			_, index, ok := it.Next()
+			if !ok {
+				break
+			}
+			rest = append(rest, index)
+		}
+		if !reflect.DeepEqual(rest, all[start:]) && !(len(rest) == 0 && int64(len(all)) == start) {
+			t.Errorf("SkipTo(%d) 后的遍历与完整遍历不一致", start)
+		}
+	}
+}
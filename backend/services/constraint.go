@@ -0,0 +1,125 @@
+package services
+
+import (
+	"fmt"
+	"sayhi/backend/models"
+)
+
+// compiledRule 编译后的约束规则：位置和值都转换为组合空间中的下标
+type compiledRule struct {
+	slot         int
+	values       map[int]bool
+	target       int
+	targetValues map[int]bool
+	requires     bool
+}
+
+// ConstraintSet 一组约束规则，用于判断组合是否有效
+type ConstraintSet struct {
+	rules   []compiledRule
+	indices []int
+}
+
+// CompileConstraints 将约束规则编译为基于下标的判断
+// 规则中引用的位置或值不在本次生成的位置值中时，该规则不起作用；
+// 条件相同（位置、值、目标位置、类型均相同）的多条规则合并，requires 规则的目标值取并集。
+// 没有生效的规则时返回 nil
+func CompileConstraints(rules []models.ConstraintRule, positionKeys []string, positionValues [][]string) (*ConstraintSet, error) {
+	slots := make(map[string]int, len(positionKeys))
+	for i, key := range positionKeys {
+		slots[key] = i
+	}
+
+	type ruleKey struct {
+		slot, target int
+		value        string
+		requires     bool
+	}
+	merged := make(map[ruleKey]int)
+	set := &ConstraintSet{}
+
+	for _, rule := range rules {
+		if rule.Type != models.RuleExcludes && rule.Type != models.RuleRequires {
+			return nil, fmt.Errorf("不支持的规则类型: %s", rule.Type)
+		}
+
+		slot, ok := slots[rule.Position]
+		if !ok {
+			continue
+		}
+		target, ok := slots[rule.TargetPosition]
+		if !ok {
+			continue
+		}
+		values := indicesOfValue(positionValues[slot], rule.Value)
+		if len(values) == 0 {
+			continue
+		}
+
+		key := ruleKey{slot: slot, target: target, value: rule.Value, requires: rule.Type == models.RuleRequires}
+		i, exists := merged[key]
+		if !exists {
+			i = len(set.rules)
+			merged[key] = i
+			set.rules = append(set.rules, compiledRule{
+				slot:         slot,
+				values:       make(map[int]bool),
+				target:       target,
+				targetValues: make(map[int]bool),
+				requires:     key.requires,
+			})
+		}
+		for _, value := range values {
+			set.rules[i].values[value] = true
+		}
+		// 目标值不存在时：excludes 规则不排除任何值，requires 规则使该条件下的组合全部无效
+		for _, targetValue := range indicesOfValue(positionValues[target], rule.TargetValue) {
+			set.rules[i].targetValues[targetValue] = true
+		}
+	}
+
+	if len(set.rules) == 0 {
+		return nil, nil
+	}
+	set.indices = make([]int, len(positionKeys))
+	return set, nil
+}
+
+// Allows 判断组合空间中下标为 index 的组合是否满足全部规则
+func (cs *ConstraintSet) Allows(space *CombinationSpace, index int64) bool {
+	if cs == nil {
+		return true
+	}
+
+	return cs.AllowsIndices(space.IndicesAt(index, cs.indices))
+}
+
+// AllowsIndices 判断各位置取值下标为 indices 的组合是否满足全部规则
+// 下标为 -1 表示该位置被省略：以它为条件的规则不起作用，以它为目标的 requires 规则不满足
+func (cs *ConstraintSet) AllowsIndices(indices []int) bool {
+	if cs == nil {
+		return true
+	}
+
+	for _, rule := range cs.rules {
+		if !rule.values[indices[rule.slot]] {
+			continue
+		}
+		matched := rule.targetValues[indices[rule.target]]
+		if matched != rule.requires {
+			return false
+		}
+	}
+	return true
+}
+
+// indicesOfValue 返回值在列表中出现的所有下标
+func indicesOfValue(values []string, value string) []int {
+	var indices []int
+	for i, v := range values {
+		if v == value {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
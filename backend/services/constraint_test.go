@@ -0,0 +1,89 @@
+package services
+
+import (
+	"sayhi/backend/models"
+	"testing"
+)
+
+func TestConstraintSetAllows(t *testing.T) {
+	keys := []string{"a", "b"}
+	values := [][]string{{"A1", "A2", "A3"}, {"B1", "B2", "B3"}}
+	excludes := func(value, target string) models.ConstraintRule {
+		return models.ConstraintRule{Position: "a", Value: value, Type: models.RuleExcludes, TargetPosition: "b", TargetValue: target}
+	}
+	requires := func(value, target string) models.ConstraintRule {
+		return models.ConstraintRule{Position: "a", Value: value, Type: models.RuleRequires, TargetPosition: "b", TargetValue: target}
+	}
+
+	tests := []struct {
+		name  string
+		rules []models.ConstraintRule
+		want  map[string]bool // 不满足规则的组合（"A1B2" 形式）
+	}{
+		{name: "没有规则", rules: nil, want: map[string]bool{}},
+		{name: "excludes", rules: []models.ConstraintRule{excludes("A1", "B2")}, want: map[string]bool{"A1B2": true}},
+		{name: "requires", rules: []models.ConstraintRule{requires("A2", "B3")}, want: map[string]bool{"A2B1": true, "A2B2": true}},
+		{
+			name:  "requires 目标值取并集",
+			rules: []models.ConstraintRule{requires("A2", "B1"), requires("A2", "B3")},
+			want:  map[string]bool{"A2B2": true},
+		},
+		{
+			name:  "requires 目标值不存在时全部无效",
+			rules: []models.ConstraintRule{requires("A3", "B9")},
+			want:  map[string]bool{"A3B1": true, "A3B2": true, "A3B3": true},
+		},
+		{name: "条件值不存在时不起作用", rules: []models.ConstraintRule{excludes("A9", "B1")}, want: map[string]bool{}},
+		{
+			name:  "位置不在模板中时不起作用",
+			rules: []models.ConstraintRule{{Position: "z", Value: "A1", Type: models.RuleExcludes, TargetPosition: "b", TargetValue: "B1"}},
+			want:  map[string]bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := CompileConstraints(tt.rules, keys, values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			space, err := NewCombinationSpace(values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for index := int64(0); index < space.Total(); index++ {
+				combo := space.At(index, nil)
+				name := combo[0] + combo[1]
+				if got := set.Allows(space, index); got == tt.want[name] {
+					t.Errorf("Allows(%s) = %v", name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileConstraintsRejectsUnknownType(t *testing.T) {
+	rules := []models.ConstraintRule{{Position: "a", Value: "A1", Type: "forbids", TargetPosition: "b", TargetValue: "B1"}}
+	if _, err := CompileConstraints(rules, []string{"a", "b"}, [][]string{{"A1"}, {"B1"}}); err == nil {
+		t.Errorf("期望不支持的规则类型返回错误")
+	}
+}
+
+func TestAllowsIndicesWithOmittedPosition(t *testing.T) {
+	rules := []models.ConstraintRule{
+		{Position: "a", Value: "A1", Type: models.RuleRequires, TargetPosition: "b", TargetValue: "B1"},
+		{Position: "b", Value: "B2", Type: models.RuleExcludes, TargetPosition: "a", TargetValue: "A1"},
+	}
+	set, err := CompileConstraints(rules, []string{"a", "b"}, [][]string{{"A1", "A2"}, {"B1", "B2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 省略的位置不满足以它为目标的 requires 规则
+	if set.AllowsIndices([]int{0, -1}) {
+		t.Errorf("省略 requires 规则的目标位置时应不满足规则")
+	}
+	// 省略的位置作为条件时规则不起作用
+	if !set.AllowsIndices([]int{-1, 1}) {
+		t.Errorf("省略规则的条件位置时规则不应起作用")
+	}
+}
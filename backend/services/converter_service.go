@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"sayhi/backend/models"
+	"sayhi/backend/utils"
+)
+
+// ConverterService 缅甸文编码转换服务（Zawgyi ⇄ Unicode）
+type ConverterService struct {
+}
+
+// NewConverterService 创建编码转换服务
+func NewConverterService() *ConverterService {
+	return &ConverterService{}
+}
+
+// IsMyanmarEncoding 判断是否为可相互转换的缅甸文编码
+func IsMyanmarEncoding(encoding models.EncodingType) bool {
+	return encoding == models.EncodingZawgyi || encoding == models.EncodingUnicode
+}
+
+// Convert 将文本从 from 编码转换为 to 编码
+func (cs *ConverterService) Convert(text string, from, to models.EncodingType) (string, error) {
+	if !IsMyanmarEncoding(from) || !IsMyanmarEncoding(to) {
+		return "", errors.New("仅支持 Zawgyi 与 Unicode 之间的转换")
+	}
+
+	switch {
+	case from == to:
+		return text, nil
+	case from == models.EncodingZawgyi:
+		return utils.ZawgyiToUnicode(text), nil
+	default:
+		return utils.UnicodeToZawgyi(text), nil
+	}
+}
+
+// ConvertAll 批量转换
+func (cs *ConverterService) ConvertAll(texts []string, from, to models.EncodingType) ([]string, error) {
+	results := make([]string, 0, len(texts))
+	for _, text := range texts {
+		converted, err := cs.Convert(text, from, to)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, converted)
+	}
+	return results, nil
+}
+
+// Detect 检测每条文本的缅甸文编码
+func (cs *ConverterService) Detect(texts []string) []models.DetectResult {
+	results := make([]models.DetectResult, 0, len(texts))
+	for _, text := range texts {
+		encoding, probability := utils.DetectMyanmarEncoding(text)
+		results = append(results, models.DetectResult{
+			Text:              text,
+			Encoding:          encoding,
+			ZawgyiProbability: probability,
+		})
+	}
+	return results
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"hash/fnv"
+	"sayhi/backend/models"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// contentDedup 按内容去重，记录已产出结果的内容摘要
+// 只保存 128 位摘要而不是内容本身，内存占用与结果数量成正比、与内容长度无关
+type contentDedup struct {
+	normalize bool
+	fold      cases.Caser
+	seen      map[[16]byte]struct{}
+}
+
+// newContentDedup 按去重方式创建去重器，不去重时返回 nil
+func newContentDedup(mode models.DedupMode) *contentDedup {
+	if mode == models.DedupNone {
+		return nil
+	}
+	return &contentDedup{
+		normalize: mode == models.DedupNormalized,
+		fold:      cases.Fold(),
+		seen:      make(map[[16]byte]struct{}),
+	}
+}
+
+// Seen 判断内容是否已经出现过，未出现过时记录下来
+func (d *contentDedup) Seen(content string) bool {
+	if d == nil {
+		return false
+	}
+
+	if d.normalize {
+		content = d.normalized(content)
+	}
+	h := fnv.New128a()
+	h.Write([]byte(content))
+	var key [16]byte
+	h.Sum(key[:0])
+
+	if _, exists := d.seen[key]; exists {
+		return true
+	}
+	d.seen[key] = struct{}{}
+	return false
+}
+
+// normalized 规范化内容：Unicode NFC（组合字符与预组合字符视为相同）、
+// 连续空白合并为一个空格并去掉首尾空白、大小写折叠
+func (d *contentDedup) normalized(content string) string {
+	content = norm.NFC.String(content)
+	content = strings.Join(strings.Fields(content), " ")
+	return d.fold.String(content)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"sayhi/backend/models"
+	"testing"
+)
+
+func TestContentDedup(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  models.DedupMode
+		texts []string
+		want  []bool // 每条内容是否被视为重复
+	}{
+		{name: "不去重", mode: models.DedupNone, texts: []string{"a", "a"}, want: []bool{false, false}},
+		{name: "完全相同", mode: models.DedupExact, texts: []string{"Hi A", "Hi A", "hi a", "Hi  A"}, want: []bool{false, true, false, false}},
+		{
+			name:  "规范化",
+			mode:  models.DedupNormalized,
+			texts: []string{"Hi A", "hi a", " Hi \t A ", "HI B"},
+			want:  []bool{false, true, true, false},
+		},
+		{
+			name:  "规范化 NFC",
+			mode:  models.DedupNormalized,
+			texts: []string{"Caf\u00e9", "Cafe\u0301", "CAF\u00c9"},
+			want:  []bool{false, true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newContentDedup(tt.mode)
+			for i, text := range tt.texts {
+				if got := d.Seen(text); got != tt.want[i] {
+					t.Errorf("Seen(%q) = %v，期望 %v", text, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
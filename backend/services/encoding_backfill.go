@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sayhi/backend/database"
+	"sayhi/backend/models"
+	"sayhi/backend/utils"
+)
+
+// encodingTables 保存检测编码的表及其内容字段
+var encodingTables = []struct {
+	table  string
+	column string
+	label  string
+}{
+	{table: "position_values", column: "value", label: "位置值"},
+	{table: "speeches", column: "content", label: "话术"},
+}
+
+// storedEncoding 返回记录中保存的编码，未检测（为空）时按内容检测
+func storedEncoding(text string, encoding models.EncodingType) models.EncodingType {
+	if encoding == "" {
+		encoding, _ = utils.DetectMyanmarEncoding(text)
+	}
+	return encoding
+}
+
+// BackfillEncodings 为尚未检测编码（encoding 为空）的位置值和话术检测编码并保存，返回更新的记录数
+// 迁移 012 将早期统一标记为 Unicode 的记录重置为空，启动时执行一次即可补齐。
+// 检测为 Zawgyi 的记录会改变字符数的计算方式，逐条记录到日志，便于核对和手动更正
+func BackfillEncodings() (int, error) {
+	updated := 0
+	for _, t := range encodingTables {
+		rows, err := database.DB.Query("SELECT id, " + t.column + " FROM " + t.table + " WHERE encoding = ''")
+		if err != nil {
+			return updated, errors.New("查询待检测编码的记录失败: " + err.Error())
+		}
+
+		type pending struct {
+			id       int64
+			encoding models.EncodingType
+		}
+		var records []pending
+		for rows.Next() {
+			var id int64
+			var text string
+			if err := rows.Scan(&id, &text); err != nil {
+				continue
+			}
+			encoding, probability := utils.DetectMyanmarEncoding(text)
+			if encoding == models.EncodingZawgyi {
+				log.Printf("%s %d 检测为 Zawgyi 编码（概率 %.2f）: %s", t.label, id, probability, text)
+			}
+			records = append(records, pending{id: id, encoding: encoding})
+		}
+		rows.Close()
+
+		if len(records) == 0 {
+			continue
+		}
+
+		tx, err := database.DB.Begin()
+		if err != nil {
+			return updated, errors.New("开启事务失败: " + err.Error())
+		}
+		for _, r := range records {
+			if _, err := tx.Exec("UPDATE "+t.table+" SET encoding = ? WHERE id = ? AND encoding = ''", r.encoding, r.id); err != nil {
+				tx.Rollback()
+				return updated, errors.New("保存检测到的编码失败: " + err.Error())
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return updated, errors.New("提交事务失败: " + err.Error())
+		}
+		updated += len(records)
+	}
+	return updated, nil
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sayhi/backend/models"
+	"strconv"
+	"strings"
+)
+
+// utf8BOM UTF-8 字节顺序标记，Excel 和部分 Windows 文本编辑器依靠它识别 UTF-8（否则缅甸文等会显示为乱码）
+const utf8BOM = "\xEF\xBB\xBF"
+
+// MaxXLSXRows Excel 工作表最多可以写入的结果行数（不含表头）
+const MaxXLSXRows = 1048575
+
+// exportColumns CSV 和 Excel 的列
+var exportColumns = []string{"content", "charCount", "segments", "smsEncoding", "isExceeded", "exceededChars"}
+
+// ResultWriter 按导出格式逐条写出生成结果，不在内存中保留已写出的结果
+type ResultWriter interface {
+	// WriteResult 写出一条结果
+	WriteResult(result *models.GeneratedResult) error
+	// Close 写出剩余内容（表头之后没有结果时也会生成完整的文件），不关闭底层的 io.Writer
+	Close() error
+}
+
+// NewResultWriter 创建指定格式的结果写出器
+// bom 只对 CSV 和纯文本有效：Excel 工作簿本身声明了编码，JSON Lines 不允许 BOM
+func NewResultWriter(format models.ExportFormat, w io.Writer, bom bool) (ResultWriter, error) {
+	switch format {
+	case models.ExportCSV:
+		return newCSVResultWriter(w, bom)
+	case models.ExportXLSX:
+		return newXLSXResultWriter(w)
+	case models.ExportText:
+		return newTextResultWriter(w, bom)
+	case models.ExportNDJSON:
+		return newNDJSONResultWriter(w), nil
+	default:
+		return nil, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+}
+
+// ExportContentType 返回导出格式的 Content-Type 和文件扩展名
+func ExportContentType(format models.ExportFormat) (contentType, extension string) {
+	switch format {
+	case models.ExportCSV:
+		return "text/csv; charset=utf-8", "csv"
+	case models.ExportXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx"
+	case models.ExportText:
+		return "text/plain; charset=utf-8", "txt"
+	default:
+		return "application/x-ndjson", "ndjson"
+	}
+}
+
+// IsValidExportFormat 判断导出格式是否有效
+func IsValidExportFormat(format models.ExportFormat) bool {
+	switch format {
+	case models.ExportCSV, models.ExportXLSX, models.ExportText, models.ExportNDJSON:
+		return true
+	default:
+		return false
+	}
+}
+
+// csvResultWriter CSV 格式：第一行为表头，内容中的逗号、引号和换行按 RFC 4180 转义，
+// 可能被 Excel 当作公式执行的内容加上单引号前缀（见 csvSafe）
+type csvResultWriter struct {
+	w *csv.Writer
+}
+
+func newCSVResultWriter(w io.Writer, bom bool) (*csvResultWriter, error) {
+	if bom {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return nil, err
+		}
+	}
+	cw := &csvResultWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(exportColumns); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvResultWriter) WriteResult(result *models.GeneratedResult) error {
+	return cw.w.Write([]string{
+		csvSafe(result.Content),
+		strconv.Itoa(result.CharCount),
+		strconv.Itoa(result.Segments),
+		string(result.SMSEncoding),
+		strconv.FormatBool(result.IsExceeded),
+		strconv.Itoa(result.ExceededChars),
+	})
+}
+
+func (cw *csvResultWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// csvSafe 以 =、+、-、@ 或制表符、回车开头的内容在 Excel 中会被当作公式执行（CSV 注入），
+// 在前面加上单引号使其按文本显示
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// textResultWriter 纯文本格式：每行一条内容，内容中的换行替换为空格以保持一行一条
+type textResultWriter struct {
+	buf *bufio.Writer
+}
+
+// lineBreaks 纯文本导出时替换为空格的换行符
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+func newTextResultWriter(w io.Writer, bom bool) (*textResultWriter, error) {
+	tw := &textResultWriter{buf: bufio.NewWriter(w)}
+	if bom {
+		if _, err := tw.buf.WriteString(utf8BOM); err != nil {
+			return nil, err
+		}
+	}
+	return tw, nil
+}
+
+func (tw *textResultWriter) WriteResult(result *models.GeneratedResult) error {
+	if _, err := lineBreaks.WriteString(tw.buf, result.Content); err != nil {
+		return err
+	}
+	return tw.buf.WriteByte('\n')
+}
+
+func (tw *textResultWriter) Close() error {
+	return tw.buf.Flush()
+}
+
+// ndjsonResultWriter JSON Lines 格式：每行一个完整的 GeneratedResult
+type ndjsonResultWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONResultWriter(w io.Writer) *ndjsonResultWriter {
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return &ndjsonResultWriter{buf: buf, enc: enc}
+}
+
+// WriteResult Encode 会在每个结果后追加换行
+func (nw *ndjsonResultWriter) WriteResult(result *models.GeneratedResult) error {
+	return nw.enc.Encode(result)
+}
+
+func (nw *ndjsonResultWriter) Close() error {
+	return nw.buf.Flush()
+}
+
+// xlsxResultWriter Excel 工作簿：只包含一个工作表，字符串使用内联字符串（不需要共享字符串表），
+// 因此可以边生成边写入压缩包；第一行为表头
+type xlsxResultWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+}
+
+// xlsxStaticParts 工作簿中除工作表外的固定部件
+var xlsxStaticParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Results" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+func newXLSXResultWriter(w io.Writer) (*xlsxResultWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxStaticParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// 工作表是最后一个部件，之后写入的行都属于它
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	xw := &xlsxResultWriter{zw: zw, sheet: bufio.NewWriter(f)}
+	xw.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	xw.sheet.WriteString(`<row>`)
+	for _, column := range exportColumns {
+		xw.writeString(column)
+	}
+	xw.sheet.WriteString(`</row>`)
+	return xw, nil
+}
+
+func (xw *xlsxResultWriter) WriteResult(result *models.GeneratedResult) error {
+	if xw.rows >= MaxXLSXRows {
+		return fmt.Errorf("Excel 工作表最多 %d 行", MaxXLSXRows)
+	}
+	xw.rows++
+
+	xw.sheet.WriteString(`<row>`)
+	xw.writeString(result.Content)
+	xw.writeNumber(result.CharCount)
+	xw.writeNumber(result.Segments)
+	xw.writeString(string(result.SMSEncoding))
+	if result.IsExceeded {
+		xw.sheet.WriteString(`<c t="b"><v>1</v></c>`)
+	} else {
+		xw.sheet.WriteString(`<c t="b"><v>0</v></c>`)
+	}
+	xw.writeNumber(result.ExceededChars)
+	_, err := xw.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (xw *xlsxResultWriter) Close() error {
+	xw.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+	return xw.zw.Close()
+}
+
+// writeString 写出内联字符串单元格，XML 中不允许的控制字符会被替换
+// 内联字符串始终按文本处理，以 = 等开头的内容不会被当作公式，因此不需要像 CSV 那样加前缀
+func (xw *xlsxResultWriter) writeString(value string) {
+	xw.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+	xml.EscapeText(xw.sheet, []byte(value))
+	xw.sheet.WriteString(`</t></is></c>`)
+}
+
+// writeNumber 写出数值单元格
+func (xw *xlsxResultWriter) writeNumber(value int) {
+	xw.sheet.WriteString(`<c><v>`)
+	xw.sheet.WriteString(strconv.Itoa(value))
+	xw.sheet.WriteString(`</v></c>`)
+}
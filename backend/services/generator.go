@@ -1,59 +1,334 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"math/rand"
 	"sayhi/backend/models"
 	"sayhi/backend/utils"
-	"strings"
 	"time"
 )
 
 const DefaultMaxCharsPerSMS = 70
 
+// MaxGenerateLimit 同步生成一页最多返回的结果数量（limit 为0或超过时按此数量返回），
+// 更多的结果需要翻页、导出或使用异步生成任务
+const MaxGenerateLimit = 10000
+
 // TemplateGenerator 模板生成器
 type TemplateGenerator struct {
 	speechService *SpeechService
+	converter     *ConverterService
 }
 
 // NewTemplateGenerator 创建新的模板生成器
 func NewTemplateGenerator(speechService *SpeechService) *TemplateGenerator {
 	return &TemplateGenerator{
 		speechService: speechService,
+		converter:     NewConverterService(),
 	}
 }
 
+// generationPlan 生成计划：解析后的位置、组合空间与计算参数
+type generationPlan struct {
+	positionKeys []string
+	space        *CombinationSpace
+	segments     []utils.TemplateSegment
+	occurrences  []int // 每个槽位在模板中出现的次数
+	literalChars int
+	literalEnc   models.EncodingType // 模板字面文本的编码
+	encodings    map[string]models.EncodingType
+	maxChars     int
+	maxSegments  int
+	mode         models.GenerateMode
+	seed         int64          // 随机生成使用的种子
+	count        int64          // 本次生成的结果总数（抽样时为抽样数量，否则为组合总数）
+	weights      [][]float64    // 各位置值的权重（nil 表示全部等权，某个位置为 nil 表示该位置等权）
+	constraints  *ConstraintSet // 约束规则（nil 表示没有规则）
+	autoFit      *autoFitPlan   // 自动适配参数（nil 表示不自动适配）
+	dedup        models.DedupMode
+	similarity   *models.SimilarityFilter
+	order        *SlotOrder // 随机生成时槽位顺序的约束（nil 表示全部打乱）
+}
+
+// generationStats 一次生成的遍历统计
+type generationStats struct {
+	scanned int64 // 遍历的组合数量（包括被约束规则剪除的组合）
+	pruned  int64 // 被约束规则剪除的组合数量
+	deduped int64 // 因内容重复被去掉的结果数量
+	similar int64 // 因与已产出的结果过于相似被去掉的结果数量
+	done    bool  // 是否已产出全部结果
+}
+
 // Generate 生成短信内容
-func (tg *TemplateGenerator) Generate(req *models.TemplateRequest) (*models.GenerateResponse, error) {
+// 只生成 [Offset, Offset+Limit) 窗口内的结果，Limit 为0或超过 MaxGenerateLimit 时最多返回 MaxGenerateLimit 条；
+// 组合总数由各位置值数量相乘得到，不需要构建全部结果；ctx 取消时停止生成并返回 ctx 的错误
+func (tg *TemplateGenerator) Generate(ctx context.Context, req *models.TemplateRequest) (*models.GenerateResponse, error) {
+	plan, err := tg.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 || limit > MaxGenerateLimit {
+		limit = MaxGenerateLimit
+	}
+
+	results := []models.GeneratedResult{}
+	exceededCount := 0
+	adjustedCount := 0
+
+	stats := tg.run(ctx, plan, req.Offset, func(result models.GeneratedResult) bool {
+		if result.IsExceeded {
+			exceededCount++
+		}
+		// 只统计调整后不再超出的结果
+		if len(result.Adjustments) > 0 && !result.IsExceeded {
+			adjustedCount++
+		}
+		results = append(results, result)
+		return len(results) < limit
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// 翻页位置按遍历的组合计算（包括被约束规则剪除的组合）
+	total := plan.space.Total()
+	nextOffset := req.Offset + stats.scanned
+	if nextOffset > total {
+		nextOffset = total
+	}
+
+	response := &models.GenerateResponse{
+		Results:       results,
+		TotalCount:    total,
+		ExceededCount: exceededCount,
+		PrunedCount:   stats.pruned,
+		AdjustedCount: adjustedCount,
+		DedupCount:    stats.deduped,
+		SimilarCount:  stats.similar,
+		Offset:        req.Offset,
+		NextOffset:    nextOffset,
+		HasMore:       !stats.done && nextOffset < total,
+		Encodings:     plan.encodings,
+	}
+	if plan.mode != models.GenerateSequential {
+		seed := plan.seed
+		response.Seed = &seed
+	}
+	if plan.mode == models.GenerateSample {
+		response.SampleSize = plan.count
+	}
+	if plan.weights != nil {
+		response.Distribution = expectedDistribution(plan)
+	}
+
+	return response, nil
+}
+
+// GenerationSummary 一次生成的统计，用于记录生成历史
+type GenerationSummary struct {
+	TotalCount    int64                          // 组合总数
+	ResultCount   int64                          // 产出的结果数量
+	ExceededCount int64                          // 超出限制的结果数量
+	Seed          *int64                         // 实际使用的种子（顺序生成时为 nil）
+	Encodings     map[string]models.EncodingType // 实际使用的各位置编码
+}
+
+// summary 返回生成计划的统计，结果数量由调用方在生成时累加
+func (plan *generationPlan) summary() *GenerationSummary {
+	summary := &GenerationSummary{
+		TotalCount: plan.space.Total(),
+		Encodings:  plan.encodings,
+	}
+	if plan.mode != models.GenerateSequential {
+		seed := plan.seed
+		summary.Seed = &seed
+	}
+	return summary
+}
+
+// Stream 逐条生成短信内容，从 Offset 开始
+// 每生成一条结果调用一次 yield，yield 返回 false 或 ctx 取消时立即停止生成，
+// 生成过程中不会在内存中保留全部组合；返回本次生成的统计（包括交给 yield 的结果数量），错误只来自解析请求
+func (tg *TemplateGenerator) Stream(ctx context.Context, req *models.TemplateRequest, yield func(models.GeneratedResult) bool) (*GenerationSummary, error) {
+	plan, err := tg.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := plan.summary()
+	tg.run(ctx, plan, req.Offset, func(result models.GeneratedResult) bool {
+		summary.ResultCount++
+		if result.IsExceeded {
+			summary.ExceededCount++
+		}
+		return yield(result)
+	})
+	return summary, nil
+}
+
+// run 按生成方式从第 start 个遍历位置开始产出结果，跳过不满足约束规则的组合，
+// 开启自动适配时调整超出限制的结果，开启去重时跳过内容与已产出的结果重复的结果（调整后的内容参与去重），
+// 开启近似重复过滤时跳过与已产出的结果距离小于最小距离的结果
+// 抽样时 count 按实际产出的结果计算（被规则、去重或近似过滤跳过的组合不计入），直到产出 count 条或遍历完全部组合。
+// start 不为0时，开启了去重或近似过滤（抽样时还包括设置了约束规则）需要用同一组过滤器重放前面的遍历，
+// 使过滤器记住前面各页已产出的结果、抽样时统计已产出的数量，因此分页得到的结果与一次生成全部结果相同
+// 每个候选组合（包括被规则、去重或近似过滤跳过的组合）之前都会检查 ctx，取消后立即返回
+func (tg *TemplateGenerator) run(ctx context.Context, plan *generationPlan, start int64, yield func(models.GeneratedResult) bool) generationStats {
+	var stats generationStats
+
+	sampling := plan.mode == models.GenerateSample
+	it := tg.iterator(plan)
+	dedup := newContentDedup(plan.dedup)
+	similarity := newSimilarityFilter(plan.similarity)
+
+	// 随机生成时打乱每个组合内的位置顺序
+	shuffle := plan.mode == models.GenerateRandom
+	shuffledKeys := make([]string, len(plan.positionKeys))
+	shuffledCombo := make([]string, len(plan.positionKeys))
+	perm := make([]int, len(plan.positionKeys))
+	// arrange 返回组合内位置的排列（perm[目标槽位] = 原位置），不打乱时为 nil
+	arrange := func(index int64) []int {
+		if !shuffle {
+			return nil
+		}
+		if plan.order != nil {
+			plan.order.Arrange(plan.seed, index, perm)
+			return perm
+		}
+		for i := range perm {
+			perm[i] = i
+		}
+		ShuffleAt(plan.seed, index, len(perm), func(i, j int) {
+			perm[i], perm[j] = perm[j], perm[i]
+		})
+		return perm
+	}
+	// build 按排列构建结果；编码按位置键映射，打乱顺序后仍然对应
+	build := func(combo []string, perm []int) models.GeneratedResult {
+		if perm == nil {
+			return tg.buildResult(plan, plan.positionKeys, combo, plan.encodings)
+		}
+		for target, source := range perm {
+			shuffledKeys[target] = plan.positionKeys[source]
+			shuffledCombo[target] = combo[source]
+		}
+		return tg.buildResult(plan, shuffledKeys, shuffledCombo, plan.encodings)
+	}
+
+	// accept 依次检查约束规则、自动适配、去重和近似过滤，返回可以产出的结果，被跳过时在 stats 中记录原因
+	// 自动适配按实际渲染的排列判断是否超出
+	accept := func(combo []string, index int64, stats *generationStats) (models.GeneratedResult, bool) {
+		if !plan.constraints.Allows(plan.space, index) {
+			stats.pruned++
+			return models.GeneratedResult{}, false
+		}
+
+		perm := arrange(index)
+		result := build(combo, perm)
+		if plan.autoFit != nil && result.IsExceeded {
+			if values, adjustments, ok := tg.autoFit(plan, index, perm); ok {
+				result = build(values, perm)
+				result.Adjustments = adjustments
+			}
+		}
+		if dedup.Seen(result.Content) {
+			stats.deduped++
+			return models.GeneratedResult{}, false
+		}
+		if similarity != nil && !similarity.Accept(result.Content) {
+			stats.similar++
+			return models.GeneratedResult{}, false
+		}
+		return result, true
+	}
+
+	remaining := plan.count
+	if start > 0 && (dedup != nil || similarity != nil || (sampling && plan.constraints != nil)) {
+		// 重放前面的遍历：让去重和近似过滤记住已产出的结果，同时统计已产出的数量
+		var replayed generationStats
+		for i := int64(0); i < start && ctx.Err() == nil; i++ {
+			combo, index, ok := it.Next()
+			if !ok {
+				break
+			}
+			if _, ok := accept(combo, index, &replayed); ok {
+				remaining--
+			}
+		}
+	} else {
+		it.SkipTo(start)
+		if sampling {
+			remaining -= start
+		}
+	}
+
+	for {
+		// 先判断是否已产出全部结果，ctx 在产出最后一条结果之后才取消时仍视为生成完成
+		if sampling && remaining <= 0 {
+			stats.done = true
+			return stats
+		}
+		combo, index, ok := it.Next()
+		if !ok {
+			stats.done = true
+			return stats
+		}
+		if ctx.Err() != nil {
+			return stats
+		}
+		stats.scanned++
+
+		result, ok := accept(combo, index, &stats)
+		if !ok {
+			continue
+		}
+		remaining--
+
+		if !yield(result) {
+			stats.done = sampling && remaining <= 0
+			return stats
+		}
+	}
+}
+
+// iterator 按生成方式创建组合迭代器
+//   - 顺序生成：按组合下标依次遍历
+//   - 随机生成：按 Feistel 置换打乱组合下标，组合顺序和组合内的位置顺序都只由种子决定，
+//     相同种子在任意分页位置都能得到相同的结果
+//   - 随机抽样：按 Feistel 置换后的组合下标遍历，每个结果只需计算一次置换，
+//     代价与抽样数量成正比，与组合总数无关；位置顺序保持模板顺序
+//
+// 随机生成和抽样设置了权重时改为按权重不重复地抽取（见 WeightedSampler），权重高的组合更早出现
+func (tg *TemplateGenerator) iterator(plan *generationPlan) *CombinationIterator {
+	if plan.mode == models.GenerateSequential {
+		return NewCombinationIterator(plan.space, SequentialOrder)
+	}
+	// 随机生成和抽样使用相同的遍历顺序，区别只在于抽样只取前 count 个、不打乱位置顺序
+	return randomIterator(plan, NewFeistelOrder(plan.space.Total(), plan.seed))
+}
+
+// prepare 解析请求，得到位置键、组合空间等生成参数
+func (tg *TemplateGenerator) prepare(req *models.TemplateRequest) (*generationPlan, error) {
 	var positionKeys []string
 	var positionValues [][]string
+	var segments []utils.TemplateSegment
 
 	// 如果提供了模板，解析模板
 	if req.Template != "" {
-		positions, _, err := utils.ParseTemplate(req.Template)
+		var err error
+		segments, err = utils.ParseTemplateSegments(req.Template)
 		if err != nil {
 			return nil, err
 		}
-		if len(positions) == 0 {
+		slots := utils.TemplateSlots(segments)
+		if len(slots) == 0 {
 			return nil, fmt.Errorf("模板为空")
 		}
 
-		// 从模板解析位置
-		positionKeys = []string{"a", "b", "c", "d"}
-		if len(positions) > len(positionKeys) {
-			// 扩展位置键
-			for i := len(positionKeys); i < len(positions); i++ {
-				positionKeys = append(positionKeys, string(rune('a'+i)))
-			}
-		}
-		positionKeys = positionKeys[:len(positions)]
-
-		// 解析位置值（支持话术组）
-		var speechGroups map[string]string
-		if req.SpeechGroups != nil {
-			speechGroups = req.SpeechGroups
-		}
-		positionValues, err = tg.resolvePositionValues(positions, req.Positions, speechGroups)
+		// 解析位置值（支持命名占位符和话术组）
+		positionKeys, positionValues, err = tg.resolvePositionValues(slots, req.Positions, req.SpeechGroups)
 		if err != nil {
 			return nil, err
 		}
@@ -64,6 +339,8 @@ func (tg *TemplateGenerator) Generate(req *models.TemplateRequest) (*models.Gene
 		}
 
 		positionKeys = req.SelectedPositions
+		// 没有模板时，各位置的值以空格连接
+		segments = utils.JoinSegments(len(positionKeys), " ")
 		var speechGroups map[string]string
 		if req.SpeechGroups != nil {
 			speechGroups = req.SpeechGroups
@@ -72,8 +349,6 @@ func (tg *TemplateGenerator) Generate(req *models.TemplateRequest) (*models.Gene
 		// 根据选择的位置获取值
 		positionValues = make([][]string, 0, len(positionKeys))
 		for _, posKey := range positionKeys {
-			var values []string
-
 			// 优先检查是否指定了话术组
 			if speechGroups != nil {
 				if speechGroupName, exists := speechGroups[posKey]; exists {
@@ -86,31 +361,7 @@ func (tg *TemplateGenerator) Generate(req *models.TemplateRequest) (*models.Gene
 			}
 
 			// 使用配置的位置值
-			switch posKey {
-			case "a":
-				values = req.Positions.A
-			case "b":
-				values = req.Positions.B
-			case "c":
-				values = req.Positions.C
-			case "d":
-				values = req.Positions.D
-			case "e":
-				values = req.Positions.E
-			case "f":
-				values = req.Positions.F
-			case "g":
-				values = req.Positions.G
-			case "h":
-				values = req.Positions.H
-			case "i":
-				values = req.Positions.I
-			case "j":
-				values = req.Positions.J
-			default:
-				return nil, fmt.Errorf("不支持的位置: %s", posKey)
-			}
-
+			values := req.Positions[posKey]
 			if len(values) == 0 {
 				return nil, fmt.Errorf("位置 %s 没有配置值或话术组", posKey)
 			}
@@ -125,200 +376,456 @@ func (tg *TemplateGenerator) Generate(req *models.TemplateRequest) (*models.Gene
 		maxChars = DefaultMaxCharsPerSMS
 	}
 
-	// 生成所有组合
-	var results []models.GeneratedResult
+	// 未指定编码的位置使用检测到的编码
+	encodings := tg.resolveEncodings(positionKeys, positionValues, req.Encodings, req.SpeechGroups)
 
-	if req.GenerateMode == models.GenerateSequential {
-		results = tg.generateSequential(positionKeys, positionValues, req.Encodings, maxChars)
-	} else {
-		results = tg.generateRandom(positionKeys, positionValues, req.Encodings, maxChars)
+	// 约束规则按转换前的值匹配
+	constraints, err := CompileConstraints(req.Rules, positionKeys, positionValues)
+	if err != nil {
+		return nil, err
 	}
 
-	// 统计超出数量
-	exceededCount := 0
-	for _, result := range results {
-		if result.IsExceeded {
-			exceededCount++
+	// 按输出编码转换缅甸文内容
+	if req.OutputEncoding != "" {
+		positionValues, encodings, err = tg.convertOutput(positionKeys, positionValues, encodings, req.OutputEncoding)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	return &models.GenerateResponse{
-		Results:       results,
-		TotalCount:    len(results),
-		ExceededCount: exceededCount,
+	space, err := NewCombinationSpace(positionValues)
+	if err != nil {
+		return nil, err
+	}
+
+	// 随机生成和抽样时按权重抽取
+	var weights [][]float64
+	if req.GenerateMode != models.GenerateSequential {
+		weights, err = tg.resolveWeights(positionKeys, positionValues, req.Weights, req.SpeechGroups)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 模板中的字面文本按模板编码计算字符数（未指定时按 Unicode）
+	literalEncoding := req.Encoding
+	if literalEncoding == "" {
+		literalEncoding = models.EncodingUnicode
+	}
+	literalChars := 0
+	occurrences := make([]int, len(positionKeys))
+	for _, seg := range segments {
+		if seg.Type == utils.SegmentLiteral {
+			literalChars += utils.CountChars(seg.Text, literalEncoding)
+		} else if seg.Slot < len(occurrences) {
+			occurrences[seg.Slot]++
+		}
+	}
+
+	// 未指定种子时使用当前时间，种子会在响应中返回，便于复现
+	seed := time.Now().UnixNano()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+
+	// 抽样生成时只产出 sampleSize 条结果
+	count := space.Total()
+	if req.GenerateMode == models.GenerateSample && req.SampleSize < count {
+		count = req.SampleSize
+	}
+
+	// 自动适配时预先计算各位置值的字符数
+	var autoFit *autoFitPlan
+	if req.AutoFit {
+		autoFit = newAutoFitPlan(positionKeys, positionValues, encodings, req.OptionalPositions)
+	}
+
+	// 位置顺序约束只在随机生成时起作用
+	var order *SlotOrder
+	if req.GenerateMode == models.GenerateRandom {
+		order, err = CompilePositionOrder(req.PositionOrder, positionKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &generationPlan{
+		positionKeys: positionKeys,
+		space:        space,
+		segments:     segments,
+		occurrences:  occurrences,
+		literalChars: literalChars,
+		literalEnc:   literalEncoding,
+		encodings:    encodings,
+		maxChars:     maxChars,
+		maxSegments:  req.MaxSegments,
+		mode:         req.GenerateMode,
+		seed:         seed,
+		count:        count,
+		weights:      weights,
+		constraints:  constraints,
+		autoFit:      autoFit,
+		dedup:        req.Dedup,
+		similarity:   req.Similarity,
+		order:        order,
 	}, nil
 }
 
-// resolvePositionValues 解析所有位置的值（支持话术组）
-func (tg *TemplateGenerator) resolvePositionValues(positions []string, config models.PositionConfig, speechGroups map[string]string) ([][]string, error) {
+// resolvePositionValues 解析模板中每个槽位的位置键和值（支持命名占位符和话术组）
+// 普通占位符按出现顺序依次对应位置 a、b、c……（见 PositionKeyAt）；命名占位符 (@name) 以名称作为位置键
+func (tg *TemplateGenerator) resolvePositionValues(slots []utils.TemplateSegment, config models.PositionConfig, speechGroups map[string]string) ([]string, [][]string, error) {
+	var positionKeys []string
 	var positionValues [][]string
 
-	// 位置标识映射
-	positionKeys := []string{"a", "b", "c", "d"}
-
-	for i, pos := range positions {
-		var configValues []string
-		positionKey := ""
-		if i < len(positionKeys) {
-			positionKey = positionKeys[i]
+	unnamed := 0
+	for _, slot := range slots {
+		if slot.Name != "" {
+			values, err := tg.resolveNamedValues(slot.Name, config, speechGroups)
+			if err != nil {
+				return nil, nil, err
+			}
+			positionKeys = append(positionKeys, slot.Name)
+			positionValues = append(positionValues, values)
+			continue
 		}
 
+		i := unnamed
+		unnamed++
+		positionKey := PositionKeyAt(i)
+		positionKeys = append(positionKeys, positionKey)
+
 		// 优先检查是否指定了话术组
-		if speechGroups != nil && positionKey != "" {
-			if speechGroupName, exists := speechGroups[positionKey]; exists {
-				speeches, err := tg.speechService.GetGroupSpeeches(speechGroupName)
-				if err == nil {
-					positionValues = append(positionValues, speeches)
-					continue
-				}
+		if speechGroupName, exists := speechGroups[positionKey]; exists {
+			speeches, err := tg.speechService.GetGroupSpeeches(speechGroupName)
+			if err == nil {
+				positionValues = append(positionValues, speeches)
+				continue
 			}
 		}
 
-		// 使用配置的位置值
-		switch i {
-		case 0: // a
-			configValues = config.A
-		case 1: // b
-			configValues = config.B
-		case 2: // c
-			configValues = config.C
-		case 3: // d
-			configValues = config.D
-		default:
-			// 支持更多位置
-			configValues = []string{pos}
+		// 使用配置的位置值，未配置时使用模板中的值
+		values, err := utils.ResolvePositionValues(slot.Text, config[positionKey])
+		if err != nil {
+			return nil, nil, fmt.Errorf("解析位置 %d 失败: %v", i, err)
+		}
+
+		positionValues = append(positionValues, values)
+	}
+
+	if err := checkPositionKeyConflicts(slots, positionKeys); err != nil {
+		return nil, nil, err
+	}
+	return positionKeys, positionValues, nil
+}
+
+// checkPositionKeyConflicts 检查命名占位符的名称是否与普通占位符按顺序得到的位置键相同
+// （如 (@a) (x) 中两个占位符的位置键都是 a），相同时约束规则、编码、权重和位置顺序无法区分这两个槽位
+func checkPositionKeyConflicts(slots []utils.TemplateSegment, positionKeys []string) error {
+	unnamed := make(map[string]int)
+	for i, slot := range slots {
+		if slot.Name == "" {
+			unnamed[positionKeys[i]] = i
+		}
+	}
+	for _, slot := range slots {
+		if slot.Name == "" {
+			continue
+		}
+		if i, exists := unnamed[slot.Name]; exists {
+			return &utils.TemplateError{
+				Pos: slot.Pos,
+				Msg: fmt.Sprintf("命名占位符 @%s 与第%d个占位符的位置键 %s 相同，请改用其它名称", slot.Name, i+1, slot.Name),
+			}
 		}
+	}
+	return nil
+}
 
-		values, err := utils.ResolvePositionValues(pos, configValues)
+// resolveNamedValues 解析命名占位符的值
+// 依次尝试：speechGroups 中为该名称指定的话术组、同名的位置配置、同名的话术组
+func (tg *TemplateGenerator) resolveNamedValues(name string, config models.PositionConfig, speechGroups map[string]string) ([]string, error) {
+	if speechGroupName, exists := speechGroups[name]; exists {
+		speeches, err := tg.speechService.GetGroupSpeeches(speechGroupName)
 		if err != nil {
-			return nil, fmt.Errorf("解析位置 %d 失败: %v", i, err)
+			return nil, fmt.Errorf("占位符 @%s 绑定的话术组不存在: %s", name, speechGroupName)
 		}
+		return speeches, nil
+	}
 
-		positionValues = append(positionValues, values)
+	if values := config[name]; len(values) > 0 {
+		return values, nil
+	}
+
+	if speeches, err := tg.speechService.GetGroupSpeeches(name); err == nil && len(speeches) > 0 {
+		return speeches, nil
 	}
 
-	return positionValues, nil
+	return nil, fmt.Errorf("占位符 @%s 没有对应的位置配置或话术组", name)
 }
 
-// generateSequential 顺序生成
-func (tg *TemplateGenerator) generateSequential(positionKeys []string, positionValues [][]string, encodings map[string]models.EncodingType, maxChars int) []models.GeneratedResult {
-	var results []models.GeneratedResult
-	combinations := tg.generateCombinations(positionValues)
-
-	for _, combo := range combinations {
-		content := tg.buildContentFromValues(positionKeys, combo)
-		// 使用每个位置对应的编码计算字符数
-		charCount := tg.countCharsWithPositionEncodings(positionKeys, combo, encodings)
-		isExceeded := utils.IsExceeded(charCount, maxChars)
-		exceededChars := 0
-		if isExceeded {
-			exceededChars = charCount - maxChars
-		}
-
-		results = append(results, models.GeneratedResult{
-			Content:       content,
-			CharCount:     charCount,
-			IsExceeded:    isExceeded,
-			ExceededChars: exceededChars,
-		})
+// PositionKeyAt 返回第 i 个（从0开始）普通占位符对应的位置键
+// 依次为 a-z，之后为 aa、ab……az、ba……，与表格列名的编号方式相同
+func PositionKeyAt(i int) string {
+	var key []byte
+	for i >= 0 {
+		key = append([]byte{byte('a' + i%26)}, key...)
+		i = i/26 - 1
+	}
+	return string(key)
+}
+
+// resolveEncodings 补全各位置的编码
+// 请求中已指定的编码保持不变；未指定时，使用话术组的话术检测编码，否则对位置值进行检测
+func (tg *TemplateGenerator) resolveEncodings(positionKeys []string, positionValues [][]string, encodings map[string]models.EncodingType, speechGroups map[string]string) map[string]models.EncodingType {
+	resolved := make(map[string]models.EncodingType, len(positionKeys))
+	for key, encoding := range encodings {
+		resolved[key] = encoding
 	}
 
-	return results
+	for i, key := range positionKeys {
+		if _, exists := resolved[key]; exists || i >= len(positionValues) {
+			continue
+		}
+
+		if speechGroupName, exists := speechGroups[key]; exists {
+			if encoding, err := tg.speechService.GetGroupEncoding(speechGroupName); err == nil {
+				resolved[key] = encoding
+				continue
+			}
+		}
+
+		resolved[key] = utils.DetectValuesEncoding(positionValues[i])
+	}
+
+	return resolved
 }
 
-// generateRandom 随机生成
-func (tg *TemplateGenerator) generateRandom(positionKeys []string, positionValues [][]string, encodings map[string]models.EncodingType, maxChars int) []models.GeneratedResult {
-	// 先生成所有组合
-	combinations := tg.generateCombinations(positionValues)
+// resolveWeights 解析各位置值的权重
+// 请求中指定的权重优先；否则使用话术组中各话术的权重（数量与值一致时）；其余位置等权。
+// 所有位置都等权时返回 nil
+func (tg *TemplateGenerator) resolveWeights(positionKeys []string, positionValues [][]string, requested map[string][]float64, speechGroups map[string]string) ([][]float64, error) {
+	weights := make([][]float64, len(positionKeys))
+	weighted := false
 
-	// 随机打乱
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(combinations), func(i, j int) {
-		combinations[i], combinations[j] = combinations[j], combinations[i]
-	})
+	for i, key := range positionKeys {
+		if i >= len(positionValues) {
+			break
+		}
 
-	var results []models.GeneratedResult
-	for _, combo := range combinations {
-		// 随机打乱位置顺序
-		shuffledKeys := make([]string, len(positionKeys))
-		shuffledCombo := make([]string, len(combo))
-		shuffledEncodings := make(map[string]models.EncodingType)
-		copy(shuffledKeys, positionKeys)
-		copy(shuffledCombo, combo)
-
-		// 同时打乱键和值，保持对应关系
-		rand.Shuffle(len(shuffledKeys), func(i, j int) {
-			shuffledKeys[i], shuffledKeys[j] = shuffledKeys[j], shuffledKeys[i]
-			shuffledCombo[i], shuffledCombo[j] = shuffledCombo[j], shuffledCombo[i]
-		})
+		w, exists := requested[key]
+		if !exists {
+			if speechGroupName, ok := speechGroups[key]; ok {
+				w, _ = tg.speechService.GetGroupWeights(speechGroupName)
+			}
+		}
+		if err := ValidateWeights(w, len(positionValues[i])); err != nil {
+			if exists {
+				return nil, fmt.Errorf("位置 %s 的权重无效: %v", key, err)
+			}
+			// 话术组的权重与值不对应时按等权处理
+			continue
+		}
+
+		if len(w) > 0 && !isUniformWeights(w) {
+			weights[i] = w
+			weighted = true
+		}
+	}
+
+	if !weighted {
+		return nil, nil
+	}
+	return weights, nil
+}
 
-		// 更新编码映射以匹配打乱后的位置
-		for i, key := range shuffledKeys {
-			if encoding, exists := encodings[positionKeys[i]]; exists {
-				shuffledEncodings[key] = encoding
+// expectedDistribution 计算设置了权重的位置中各值的期望出现概率（按权重独立抽取时的概率）
+func expectedDistribution(plan *generationPlan) map[string][]models.ValueProbability {
+	distribution := make(map[string][]models.ValueProbability)
+	for i, weights := range plan.weights {
+		if weights == nil {
+			continue
+		}
+
+		sum := 0.0
+		for _, w := range weights {
+			sum += w
+		}
+		values := plan.space.values[i]
+		probabilities := make([]models.ValueProbability, len(values))
+		for j, value := range values {
+			probabilities[j] = models.ValueProbability{
+				Value:       value,
+				Weight:      weights[j],
+				Probability: weights[j] / sum,
 			}
 		}
+		distribution[plan.positionKeys[i]] = probabilities
+	}
+	return distribution
+}
 
-		content := tg.buildContentFromValues(shuffledKeys, shuffledCombo)
-		// 使用每个位置对应的编码计算字符数
-		charCount := tg.countCharsWithPositionEncodings(shuffledKeys, shuffledCombo, shuffledEncodings)
-		isExceeded := utils.IsExceeded(charCount, maxChars)
-		exceededChars := 0
-		if isExceeded {
-			exceededChars = charCount - maxChars
+// randomIterator 创建随机遍历组合的迭代器：设置了权重时按权重抽取，否则使用 order 给出的置换顺序
+func randomIterator(plan *generationPlan, order IndexOrder) *CombinationIterator {
+	if plan.weights != nil {
+		return NewWeightedIterator(plan.space, NewWeightedSampler(plan.space, plan.weights, plan.seed))
+	}
+	return NewCombinationIterator(plan.space, order)
+}
+
+// convertOutput 将 Zawgyi/Unicode 位置的值转换为输出编码，返回转换后的值和编码映射
+// 其它编码的位置保持不变
+func (tg *TemplateGenerator) convertOutput(positionKeys []string, positionValues [][]string, encodings map[string]models.EncodingType, output models.EncodingType) ([][]string, map[string]models.EncodingType, error) {
+	converted := make([][]string, len(positionValues))
+	convertedEncodings := make(map[string]models.EncodingType, len(encodings))
+	for key, encoding := range encodings {
+		convertedEncodings[key] = encoding
+	}
+
+	for i, values := range positionValues {
+		converted[i] = values
+		if i >= len(positionKeys) {
+			continue
 		}
 
-		results = append(results, models.GeneratedResult{
-			Content:       content,
-			CharCount:     charCount,
-			IsExceeded:    isExceeded,
-			ExceededChars: exceededChars,
-		})
+		key := positionKeys[i]
+		encoding, exists := encodings[key]
+		if !exists || !IsMyanmarEncoding(encoding) || encoding == output {
+			continue
+		}
+
+		values, err := tg.converter.ConvertAll(values, encoding, output)
+		if err != nil {
+			return nil, nil, fmt.Errorf("转换位置 %s 的编码失败: %v", key, err)
+		}
+		converted[i] = values
+		convertedEncodings[key] = output
 	}
 
-	return results
+	return converted, convertedEncodings, nil
 }
 
-// generateCombinations 生成所有组合（笛卡尔积）
-func (tg *TemplateGenerator) generateCombinations(positionValues [][]string) [][]string {
-	if len(positionValues) == 0 {
-		return [][]string{}
+// buildResult 根据一个组合构建生成结果
+// 超出字符限制，或指定了最大分段数且分段数超出时，结果标记为超出
+func (tg *TemplateGenerator) buildResult(plan *generationPlan, positionKeys []string, combo []string, encodings map[string]models.EncodingType) models.GeneratedResult {
+	content := tg.buildContentFromValues(plan.segments, combo)
+	// 使用每个位置对应的编码计算字符数，再加上模板字面文本的字符数
+	charCount := tg.countCharsWithPositionEncodings(positionKeys, combo, encodings, plan.occurrences) + plan.literalChars
+	exceededChars := 0
+	if utils.IsExceeded(charCount, plan.maxChars) {
+		exceededChars = charCount - plan.maxChars
 	}
 
-	if len(positionValues) == 1 {
-		var result [][]string
-		for _, val := range positionValues[0] {
-			result = append(result, []string{val})
+	// 按实际发送编码计算短信分段
+	seg := utils.SplitSegments(content)
+	segmentsExceeded := plan.maxSegments > 0 && len(seg.Segments) > plan.maxSegments
+
+	// 标识超出部分的起始位置
+	var overflow *models.OverflowMark
+	if exceededChars > 0 || segmentsExceeded {
+		segmentCut := -1
+		if segmentsExceeded {
+			segmentCut = seg.Segments[plan.maxSegments-1].End
+		}
+		overflow = tg.overflowMark(plan, positionKeys, combo, encodings, segmentCut)
+	}
+
+	return models.GeneratedResult{
+		Content:           content,
+		CharCount:         charCount,
+		IsExceeded:        exceededChars > 0 || segmentsExceeded,
+		ExceededChars:     exceededChars,
+		UnsupportedChars:  tg.collectUnsupportedChars(positionKeys, combo, encodings),
+		SMSEncoding:       seg.Encoding,
+		Segments:          len(seg.Segments),
+		SegmentBoundaries: seg.Segments,
+		RemainingUnits:    seg.RemainingUnits,
+		UCS2Chars:         seg.UCS2Chars,
+		Overflow:          overflow,
+	}
+}
+
+// overflowMark 按模板片段逐字符累计字符数（字面文本按模板编码，占位符按对应位置的编码），
+// 找到首次超过最大字符数的字符，或到达 segmentCut（字符偏移，-1 表示不限制）的位置，
+// 并列出与超出部分重叠的位置
+func (tg *TemplateGenerator) overflowMark(plan *generationPlan, positionKeys []string, combo []string, encodings map[string]models.EncodingType, segmentCut int) *models.OverflowMark {
+	var mark *models.OverflowMark
+	charCount, byteOffset, runeOffset := 0, 0, 0
+
+	for _, segment := range plan.segments {
+		text := segment.Text
+		encoding := plan.literalEnc
+		key := ""
+		if segment.Type != utils.SegmentLiteral {
+			if segment.Slot >= len(combo) {
+				continue
+			}
+			text = combo[segment.Slot]
+			key = positionKeys[segment.Slot]
+			encoding = models.EncodingUnicode
+			if enc, exists := encodings[key]; exists {
+				encoding = enc
+			}
+		}
+
+		start := runeOffset
+		for i, r := range text {
+			if mark == nil {
+				charCount += utils.RuneChars(r, encoding)
+				if utils.IsExceeded(charCount, plan.maxChars) || (segmentCut >= 0 && runeOffset >= segmentCut) {
+					mark = &models.OverflowMark{
+						ByteOffset: byteOffset + i,
+						RuneOffset: runeOffset,
+						Positions:  []models.OverflowPosition{},
+					}
+				}
+			}
+			runeOffset++
+		}
+		byteOffset += len(text)
+
+		if mark != nil && key != "" && runeOffset > start {
+			mark.Positions = append(mark.Positions, models.OverflowPosition{
+				Position:  key,
+				Start:     start,
+				End:       runeOffset,
+				Straddles: start < mark.RuneOffset,
+			})
 		}
-		return result
 	}
 
-	// 递归生成组合
-	rest := tg.generateCombinations(positionValues[1:])
-	var result [][]string
+	return mark
+}
 
-	for _, val := range positionValues[0] {
-		for _, combo := range rest {
-			newCombo := append([]string{val}, combo...)
-			result = append(result, newCombo)
+// collectUnsupportedChars 收集 GSM-7 编码位置中无法用 GSM-7 表示的字符
+func (tg *TemplateGenerator) collectUnsupportedChars(positionKeys []string, values []string, encodings map[string]models.EncodingType) []string {
+	var unsupported []string
+	seen := make(map[string]bool)
+
+	for i, key := range positionKeys {
+		if i >= len(values) || encodings[key] != models.EncodingGSM7 {
+			continue
+		}
+		_, chars := utils.CountGSM7(values[i])
+		for _, ch := range chars {
+			if !seen[ch] {
+				seen[ch] = true
+				unsupported = append(unsupported, ch)
+			}
 		}
 	}
 
-	return result
+	return unsupported
 }
 
-// buildContentFromValues 根据位置键和值构建内容
-func (tg *TemplateGenerator) buildContentFromValues(_ []string, values []string) string {
-	// 按位置顺序组合：a b c d 的值
-	return strings.Join(values, " ")
+// buildContentFromValues 按模板片段将值填入对应占位符，保留占位符之间的字面文本
+func (tg *TemplateGenerator) buildContentFromValues(segments []utils.TemplateSegment, values []string) string {
+	return utils.RenderSegments(segments, values)
 }
 
-// countCharsWithPositionEncodings 使用每个位置对应的编码计算总字符数
-// 每个位置的值使用该位置对应的编码来计算字符数，然后相加
-// 空格按ASCII编码计算（每个空格1个字符）
-func (tg *TemplateGenerator) countCharsWithPositionEncodings(positionKeys []string, values []string, encodings map[string]models.EncodingType) int {
+// countCharsWithPositionEncodings 使用每个位置对应的编码计算所有位置值的字符数
+// 每个位置的值使用该位置对应的编码来计算字符数，乘以该槽位在模板中出现的次数后相加（不含模板字面文本）
+func (tg *TemplateGenerator) countCharsWithPositionEncodings(positionKeys []string, values []string, encodings map[string]models.EncodingType, occurrences []int) int {
 	totalCount := 0
-	spaceCount := len(values) - 1 // 空格的数量
 
 	for i, key := range positionKeys {
 		if i < len(values) {
@@ -327,12 +834,13 @@ func (tg *TemplateGenerator) countCharsWithPositionEncodings(positionKeys []stri
 				encoding = enc
 			}
 			// 计算该位置值的字符数
-			totalCount += utils.CountChars(values[i], encoding)
+			count := utils.CountChars(values[i], encoding)
+			if i < len(occurrences) {
+				count *= occurrences[i]
+			}
+			totalCount += count
 		}
 	}
 
-	// 添加空格（空格按ASCII计算，每个空格1个字符）
-	totalCount += spaceCount
-
 	return totalCount
 }
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sayhi/backend/models"
+	"testing"
+)
+
+// generateAll 按 limit 逐页生成，直到 hasMore 为 false，返回各页内容
+func generateAll(t *testing.T, req models.TemplateRequest, limit int) []string {
+	t.Helper()
+	tg := NewTemplateGenerator(NewSpeechService())
+
+	var contents []string
+	req.Limit = limit
+	for page := 0; ; page++ {
+		if page > 1000 {
+			t.Fatalf("翻页次数过多")
+		}
+		resp, err := tg.Generate(context.Background(), &req)
+		if err != nil {
+			t.Fatalf("生成失败: %v", err)
+		}
+		for _, r := range resp.Results {
+			contents = append(contents, r.Content)
+		}
+		if !resp.HasMore {
+			return contents
+		}
+		req.Offset = resp.NextOffset
+	}
+}
+
+func TestGeneratePagingKeepsFilters(t *testing.T) {
+	seed := int64(7)
+	tests := []struct {
+		name string
+		req  models.TemplateRequest
+	}{
+		{
+			name: "顺序生成去重",
+			req: models.TemplateRequest{
+				Template:     "Hi (a), visit (b) today!",
+				GenerateMode: models.GenerateSequential,
+				Positions:    models.PositionConfig{"a": {"X", "X", "Y"}, "b": {"B"}},
+				Dedup:        models.DedupExact,
+			},
+		},
+		{
+			name: "随机生成去重",
+			req: models.TemplateRequest{
+				Template:     "(a) (b)",
+				GenerateMode: models.GenerateRandom,
+				Positions:    models.PositionConfig{"a": {"X", "X", "Y", "Y"}, "b": {"1", "1", "2"}},
+				Dedup:        models.DedupExact,
+				Seed:         &seed,
+			},
+		},
+		{
+			name: "抽样去重",
+			req: models.TemplateRequest{
+				Template:     "(a)-(b)",
+				GenerateMode: models.GenerateSample,
+				Positions:    models.PositionConfig{"a": {"X", "X", "Y", "Z"}, "b": {"1", "1", "2"}},
+				Dedup:        models.DedupExact,
+				SampleSize:   5,
+				Seed:         &seed,
+			},
+		},
+		{
+			name: "顺序生成近似过滤",
+			req: models.TemplateRequest{
+				Template:     "x(1-60)",
+				GenerateMode: models.GenerateSequential,
+				Similarity:   &models.SimilarityFilter{Method: models.SimilarityEdit, MinDistance: 2},
+			},
+		},
+		{
+			name: "抽样近似过滤",
+			req: models.TemplateRequest{
+				Template:     "x(1-300)",
+				GenerateMode: models.GenerateSample,
+				Similarity:   &models.SimilarityFilter{Method: models.SimilarityEdit, MinDistance: 2},
+				SampleSize:   12,
+				Seed:         &seed,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := generateAll(t, tt.req, 0)
+			for _, limit := range []int{1, 2, 5} {
+				got := generateAll(t, tt.req, limit)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("limit=%d 分页结果 %q，一次生成的结果 %q", limit, got, want)
+				}
+			}
+
+			seen := make(map[string]bool)
+			for _, content := range want {
+				if tt.req.Dedup != models.DedupNone && seen[content] {
+					t.Errorf("结果 %q 重复出现", content)
+				}
+				seen[content] = true
+			}
+		})
+	}
+}
+
+func TestGenerateSampleCountsAcceptedResults(t *testing.T) {
+	seed := int64(42)
+	req := models.TemplateRequest{
+		Template:     "x(1-300)",
+		GenerateMode: models.GenerateSample,
+		SampleSize:   12,
+		Seed:         &seed,
+		Similarity:   &models.SimilarityFilter{Method: models.SimilarityEdit, MinDistance: 2},
+	}
+	if got := generateAll(t, req, 0); len(got) != 12 {
+		t.Errorf("抽样数量为12时得到 %d 条结果", len(got))
+	}
+
+	// 不重复的内容只有3种，抽样数量超出时返回全部不重复的结果
+	req = models.TemplateRequest{
+		Template:     "(a)",
+		GenerateMode: models.GenerateSample,
+		Positions:    models.PositionConfig{"a": {"X", "X", "Y", "Z", "Z"}},
+		Dedup:        models.DedupExact,
+		SampleSize:   4,
+		Seed:         &seed,
+	}
+	if got := generateAll(t, req, 0); len(got) != 3 {
+		t.Errorf("只有3种不重复的内容时得到 %d 条结果: %q", len(got), got)
+	}
+}
+
+// ctx 在产出最后一条结果之后才取消时，生成仍然视为完成
+func TestRunCancelAfterLastResult(t *testing.T) {
+	tg := NewTemplateGenerator(NewSpeechService())
+	for _, mode := range []models.GenerateMode{models.GenerateSequential, models.GenerateSample} {
+		req := &models.TemplateRequest{Template: "(1-5)", GenerateMode: mode, SampleSize: 3}
+		plan, err := tg.prepare(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		produced := int64(0)
+		stats := tg.run(ctx, plan, 0, func(models.GeneratedResult) bool {
+			produced++
+			if produced == plan.count {
+				cancel()
+			}
+			return true
+		})
+		if !stats.done || produced != plan.count {
+			t.Errorf("%s: 产出 %d 条，done=%v", mode, produced, stats.done)
+		}
+
+		// 中途取消时不应视为完成
+		ctx, cancel = context.WithCancel(context.Background())
+		stats = tg.run(ctx, plan, 0, func(models.GeneratedResult) bool {
+			cancel()
+			return true
+		})
+		if stats.done {
+			t.Errorf("%s: 中途取消后 done=true", mode)
+		}
+	}
+}
+
+func TestGenerateClampsLimit(t *testing.T) {
+	tg := NewTemplateGenerator(NewSpeechService())
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{name: "未指定", limit: 0, want: MaxGenerateLimit},
+		{name: "超过上限", limit: MaxGenerateLimit * 3, want: MaxGenerateLimit},
+		{name: "上限以内", limit: 10, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.TemplateRequest{Template: "(1-20)(1-1000)", GenerateMode: models.GenerateSequential, Limit: tt.limit}
+			resp, err := tg.Generate(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Results) != tt.want || !resp.HasMore || resp.NextOffset != int64(tt.want) {
+				t.Errorf("返回 %d 条，hasMore=%v，nextOffset=%d，期望 %d 条", len(resp.Results), resp.HasMore, resp.NextOffset, tt.want)
+			}
+		})
+	}
+}
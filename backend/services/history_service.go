@@ -0,0 +1,158 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sayhi/backend/database"
+	"sayhi/backend/models"
+	"strings"
+	"time"
+)
+
+// HistoryService 生成历史服务（使用数据库存储）
+type HistoryService struct{}
+
+// NewHistoryService 创建生成历史服务
+func NewHistoryService() *HistoryService {
+	return &HistoryService{}
+}
+
+// HistoryFilter 生成历史查询条件，零值表示不限制
+type HistoryFilter struct {
+	From     time.Time // 起始时间（包含）
+	To       time.Time // 结束时间（不包含）
+	Page     int       // 页码（从1开始）
+	PageSize int       // 每页条数
+}
+
+// Record 记录一次生成（同步生成、导出或异步任务）
+// 保存的生成参数中种子替换为实际使用的种子，按记录重新生成可以得到相同的结果
+func (hs *HistoryService) Record(userID int64, req *models.TemplateRequest, summary *GenerationSummary, duration time.Duration) error {
+	params := *req
+	if summary.Seed != nil {
+		seed := *summary.Seed
+		params.Seed = &seed
+	}
+	request, err := json.Marshal(&params)
+	if err != nil {
+		return errors.New("序列化生成参数失败: " + err.Error())
+	}
+	encodings, err := json.Marshal(summary.Encodings)
+	if err != nil {
+		return errors.New("序列化编码失败: " + err.Error())
+	}
+
+	encoding := req.Encoding
+	if encoding == "" {
+		encoding = models.EncodingUnicode
+	}
+	var templateID sql.NullInt64
+	var templateVersion sql.NullInt64
+	if req.TemplateID > 0 {
+		templateID = sql.NullInt64{Int64: req.TemplateID, Valid: true}
+		templateVersion = sql.NullInt64{Int64: int64(req.TemplateVersion), Valid: true}
+	}
+	var seed sql.NullInt64
+	if summary.Seed != nil {
+		seed = sql.NullInt64{Int64: *summary.Seed, Valid: true}
+	}
+
+	_, err = database.DB.Exec(`INSERT INTO generate_history (user_id, template, encoding, encodings, generate_mode, seed,
+		total_count, result_count, exceeded_count, duration_ms, template_id, template_version, request)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, req.Template, encoding, string(encodings), req.GenerateMode, seed,
+		summary.TotalCount, summary.ResultCount, summary.ExceededCount, duration.Milliseconds(), templateID, templateVersion, string(request))
+	if err != nil {
+		return errors.New("记录生成历史失败: " + err.Error())
+	}
+	return nil
+}
+
+// GetHistory 获取用户的一条生成历史（包含完整的生成参数）
+func (hs *HistoryService) GetHistory(id, userID int64) (*models.GenerateHistory, error) {
+	row := database.DB.QueryRow(`SELECT h.id, h.user_id, COALESCE(u.username, ''), h.template, h.encoding, h.encodings,
+		h.generate_mode, h.seed, h.total_count, h.result_count, h.exceeded_count, h.duration_ms,
+		h.template_id, h.template_version, h.created_at, h.request
+		FROM generate_history h LEFT JOIN users u ON u.id = h.user_id
+		WHERE h.id = ? AND h.user_id = ?`, id, userID)
+
+	var request sql.NullString
+	history, err := scanHistory(row, &request)
+	if err != nil {
+		return nil, errors.New("生成历史不存在")
+	}
+	if request.Valid && request.String != "" {
+		history.Request = &models.TemplateRequest{}
+		if err := json.Unmarshal([]byte(request.String), history.Request); err != nil {
+			return nil, errors.New("解析生成参数失败: " + err.Error())
+		}
+	}
+	return history, nil
+}
+
+// ListHistory 按条件查询用户的生成历史（按时间从新到旧排列），返回本页记录和符合条件的总数
+func (hs *HistoryService) ListHistory(userID int64, filter HistoryFilter) ([]models.GenerateHistory, int64, error) {
+	conditions := []string{"h.user_id = ?"}
+	args := []interface{}{userID}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "h.created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "h.created_at < ?")
+		args = append(args, filter.To)
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := database.DB.QueryRow("SELECT COUNT(*) FROM generate_history h"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.New("查询生成历史失败: " + err.Error())
+	}
+
+	rows, err := database.DB.Query(`SELECT h.id, h.user_id, COALESCE(u.username, ''), h.template, h.encoding, h.encodings,
+		h.generate_mode, h.seed, h.total_count, h.result_count, h.exceeded_count, h.duration_ms,
+		h.template_id, h.template_version, h.created_at
+		FROM generate_history h LEFT JOIN users u ON u.id = h.user_id`+where+`
+		ORDER BY h.id DESC LIMIT ? OFFSET ?`,
+		append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)...)
+	if err != nil {
+		return nil, 0, errors.New("查询生成历史失败: " + err.Error())
+	}
+	defer rows.Close()
+
+	history := []models.GenerateHistory{}
+	for rows.Next() {
+		h, err := scanHistory(rows)
+		if err != nil {
+			continue
+		}
+		history = append(history, *h)
+	}
+
+	return history, total, nil
+}
+
+// scanHistory 读取一行生成历史，extra 为追加在固定字段之后的列
+func scanHistory(row rowScanner, extra ...interface{}) (*models.GenerateHistory, error) {
+	var h models.GenerateHistory
+	var encodings sql.NullString
+	var seed, templateID, templateVersion sql.NullInt64
+	dest := []interface{}{&h.ID, &h.UserID, &h.Username, &h.Template, &h.Encoding, &encodings,
+		&h.GenerateMode, &seed, &h.TotalCount, &h.ResultCount, &h.ExceededCount, &h.DurationMs,
+		&templateID, &templateVersion, &h.CreatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if encodings.Valid && encodings.String != "" {
+		_ = json.Unmarshal([]byte(encodings.String), &h.Encodings)
+	}
+	if seed.Valid {
+		h.Seed = &seed.Int64
+	}
+	h.TemplateID = templateID.Int64
+	h.TemplateVersion = int(templateVersion.Int64)
+	return &h, nil
+}
@@ -0,0 +1,499 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sayhi/backend/database"
+	"sayhi/backend/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jobResultBatchSize = 500 // 生成任务每次批量写入的结果数量
+	maxJobErrorLength  = 500 // 保存的失败原因最大长度（与 error 字段长度一致）
+
+	// DefaultMaxJobResults 单个任务默认最多生成的结果数量
+	DefaultMaxJobResults = 10000000
+)
+
+var (
+	// ErrJobQueueFull 任务队列已满
+	ErrJobQueueFull = errors.New("生成任务队列已满，请稍后再试")
+	// ErrJobTooLarge 任务的预计结果数量超过上限
+	ErrJobTooLarge = errors.New("生成任务的结果数量超过上限")
+)
+
+// JobService 异步生成任务服务
+// 任务和结果保存在数据库中，排队和生成中的任务同时保存在内存中，进度只在内存中更新，任务结束时写回数据库。
+// 任务提交时即解析生成参数（模板错误等直接返回），随后由固定数量的工作协程按提交顺序生成
+type JobService struct {
+	generator  *TemplateGenerator
+	history    *HistoryService
+	queue      chan int64
+	maxResults int64
+
+	mu     sync.Mutex
+	active map[int64]*activeJob
+}
+
+// activeJob 排队或生成中的任务
+type activeJob struct {
+	job    models.GenerateJob
+	req    *models.TemplateRequest
+	plan   *generationPlan
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewJobService 创建生成任务服务，queueSize 为最多排队的任务数量，maxResults 为单个任务最多生成的结果数量；
+// 任务结束时记录到 historyService 的生成历史
+func NewJobService(speechService *SpeechService, historyService *HistoryService, queueSize int, maxResults int64) *JobService {
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	if maxResults <= 0 {
+		maxResults = DefaultMaxJobResults
+	}
+	return &JobService{
+		generator:  NewTemplateGenerator(speechService),
+		history:    historyService,
+		queue:      make(chan int64, queueSize),
+		maxResults: maxResults,
+		active:     make(map[int64]*activeJob),
+	}
+}
+
+// Start 启动 workers 个工作协程
+// 上次运行时未结束的任务已经无法继续，标记为失败
+func (js *JobService) Start(workers int) {
+	_, err := database.DB.Exec(`UPDATE generate_jobs SET status = ?, error = ?, finished_at = CURRENT_TIMESTAMP
+		WHERE status IN (?, ?)`, models.JobFailed, "服务重启，任务已中断", models.JobQueued, models.JobRunning)
+	if err != nil {
+		log.Println("更新中断的生成任务失败: " + err.Error())
+	}
+
+	if workers <= 0 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go js.worker()
+	}
+}
+
+// Submit 提交生成任务，随机生成和抽样时固定种子，使结果可以复现
+// 预计结果数量超过 maxResults 时返回 ErrJobTooLarge
+func (js *JobService) Submit(userID int64, req *models.TemplateRequest) (*models.GenerateJob, error) {
+	plan, err := js.generator.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	params := *req
+	if plan.mode != models.GenerateSequential {
+		seed := plan.seed
+		params.Seed = &seed
+	}
+	request, err := json.Marshal(&params)
+	if err != nil {
+		return nil, errors.New("序列化生成参数失败: " + err.Error())
+	}
+
+	// 预计结果数量：从 offset 开始的剩余组合（抽样时为剩余的抽样数量），不超过 limit
+	expected := plan.count - req.Offset
+	if expected < 0 {
+		expected = 0
+	}
+	if req.Limit > 0 && int64(req.Limit) < expected {
+		expected = int64(req.Limit)
+	}
+	// 结果全部写入数据库，limit 为0时可能是整个组合空间，超过上限的任务直接拒绝
+	if expected > js.maxResults {
+		return nil, fmt.Errorf("%w（预计 %d 条，最多 %d 条），请设置 limit 或使用抽样", ErrJobTooLarge, expected, js.maxResults)
+	}
+
+	result, err := database.DB.Exec(`INSERT INTO generate_jobs (user_id, status, request, seed, total_count, expected_count)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		userID, models.JobQueued, string(request), params.Seed, plan.space.Total(), expected)
+	if err != nil {
+		return nil, errors.New("创建生成任务失败: " + err.Error())
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, errors.New("获取任务ID失败: " + err.Error())
+	}
+
+	job, err := js.loadJob(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 内存中的任务状态不包含生成参数，列表中直接使用
+	snapshot := *job
+	snapshot.Request = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	js.mu.Lock()
+	js.active[id] = &activeJob{job: snapshot, req: &params, plan: plan, ctx: ctx, cancel: cancel}
+	js.mu.Unlock()
+
+	select {
+	case js.queue <- id:
+	default:
+		js.mu.Lock()
+		delete(js.active, id)
+		js.mu.Unlock()
+		cancel()
+		_, _ = database.DB.Exec("DELETE FROM generate_jobs WHERE id = ?", id)
+		return nil, ErrJobQueueFull
+	}
+
+	return job, nil
+}
+
+// GetJob 获取任务（包含生成参数），排队和生成中的任务返回内存中的最新进度
+func (js *JobService) GetJob(id, userID int64) (*models.GenerateJob, error) {
+	js.mu.Lock()
+	if active, exists := js.active[id]; exists && active.job.UserID == userID {
+		job := active.job
+		js.mu.Unlock()
+		job.Request = active.req
+		return &job, nil
+	}
+	js.mu.Unlock()
+
+	return js.loadJob(id, userID)
+}
+
+// GetAllJobs 获取用户的所有任务（按提交时间从新到旧排列，不包含生成参数）
+func (js *JobService) GetAllJobs(userID int64) ([]models.GenerateJob, error) {
+	rows, err := database.DB.Query(`SELECT id, user_id, status, seed, total_count, expected_count, result_count,
+		exceeded_count, error, created_at, started_at, finished_at
+		FROM generate_jobs WHERE user_id = ? ORDER BY id DESC`, userID)
+	if err != nil {
+		return nil, errors.New("查询生成任务失败: " + err.Error())
+	}
+	defer rows.Close()
+
+	jobs := []models.GenerateJob{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, *job)
+	}
+
+	// 未结束的任务使用内存中的最新进度
+	js.mu.Lock()
+	for i := range jobs {
+		if active, exists := js.active[jobs[i].ID]; exists {
+			jobs[i] = active.job
+		}
+	}
+	js.mu.Unlock()
+
+	return jobs, nil
+}
+
+// CancelJob 取消排队或生成中的任务，生成中的任务在写入当前结果后停止
+func (js *JobService) CancelJob(id, userID int64) (*models.GenerateJob, error) {
+	job, err := js.GetJob(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Finished() {
+		return nil, errors.New("任务已结束")
+	}
+
+	js.mu.Lock()
+	active, exists := js.active[id]
+	if exists {
+		active.cancel()
+		// 排队中的任务直接标记为已取消，工作协程取到时会跳过
+		if active.job.Status == models.JobQueued {
+			delete(js.active, id)
+			exists = false
+		}
+	}
+	js.mu.Unlock()
+
+	if !exists {
+		// 只更新仍在排队的任务，任务可能在此之前已经结束
+		_, err := database.DB.Exec(`UPDATE generate_jobs SET status = ?, finished_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ?`,
+			models.JobCanceled, id, models.JobQueued)
+		if err != nil {
+			return nil, errors.New("取消生成任务失败: " + err.Error())
+		}
+	}
+
+	return js.GetJob(id, userID)
+}
+
+// DeleteJob 删除任务及其结果，未结束的任务先取消
+func (js *JobService) DeleteJob(id, userID int64) error {
+	if _, err := js.GetJob(id, userID); err != nil {
+		return err
+	}
+
+	js.mu.Lock()
+	if active, exists := js.active[id]; exists {
+		active.cancel()
+		delete(js.active, id)
+	}
+	js.mu.Unlock()
+
+	// 结果随任务级联删除
+	if _, err := database.DB.Exec("DELETE FROM generate_jobs WHERE id = ? AND user_id = ?", id, userID); err != nil {
+		return errors.New("删除生成任务失败: " + err.Error())
+	}
+	return nil
+}
+
+// GetResults 分页获取任务已生成的结果（任务未结束时也可以获取已生成的部分）
+func (js *JobService) GetResults(id, userID, offset int64, limit int) (*models.JobResultsResponse, error) {
+	job, err := js.GetJob(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.JobResultsResponse{
+		JobID:   id,
+		Status:  job.Status,
+		Results: []models.GeneratedResult{},
+		Offset:  offset,
+	}
+	err = js.readResults(id, offset, limit, func(result *models.GeneratedResult) error {
+		response.Results = append(response.Results, *result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response.NextOffset = offset + int64(len(response.Results))
+	response.HasMore = response.NextOffset < job.ResultCount
+	return response, nil
+}
+
+// StreamResults 按生成顺序逐条读取任务的全部结果，用于下载
+func (js *JobService) StreamResults(id, userID int64, yield func(result *models.GeneratedResult) error) error {
+	if _, err := js.GetJob(id, userID); err != nil {
+		return err
+	}
+	return js.readResults(id, 0, 0, yield)
+}
+
+// readResults 读取序号从 offset 开始的 limit 条结果（limit 为0时读取全部）
+func (js *JobService) readResults(id, offset int64, limit int, yield func(result *models.GeneratedResult) error) error {
+	query := "SELECT result FROM generate_job_results WHERE job_id = ? AND seq >= ? ORDER BY seq"
+	args := []interface{}{id, offset}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return errors.New("查询任务结果失败: " + err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return errors.New("读取任务结果失败: " + err.Error())
+		}
+		var result models.GeneratedResult
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			return errors.New("解析任务结果失败: " + err.Error())
+		}
+		if err := yield(&result); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// worker 按提交顺序执行队列中的任务
+func (js *JobService) worker() {
+	for id := range js.queue {
+		js.runJob(id)
+	}
+}
+
+// runJob 执行一个任务：逐条生成并批量写入结果，同时更新内存中的进度，结束后将状态写回数据库
+func (js *JobService) runJob(id int64) {
+	js.mu.Lock()
+	active, exists := js.active[id]
+	if !exists {
+		// 排队时已被取消或删除
+		js.mu.Unlock()
+		return
+	}
+	active.job.Status = models.JobRunning
+	js.mu.Unlock()
+
+	_, err := database.DB.Exec("UPDATE generate_jobs SET status = ?, started_at = CURRENT_TIMESTAMP WHERE id = ?",
+		models.JobRunning, id)
+	if err == nil {
+		if job, loadErr := js.loadJob(id, active.job.UserID); loadErr == nil {
+			js.mu.Lock()
+			active.job.StartedAt = job.StartedAt
+			js.mu.Unlock()
+		}
+	}
+
+	var (
+		batch    []models.GeneratedResult
+		seq      int64
+		exceeded int64
+		runErr   = err
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := insertJobResults(id, seq-int64(len(batch)), batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+
+		js.mu.Lock()
+		active.job.ResultCount = seq
+		active.job.ExceededCount = exceeded
+		active.job.Progress = jobProgress(seq, active.job.ExpectedCount)
+		js.mu.Unlock()
+		return nil
+	}
+
+	// finished 生成是否正常结束（产出全部结果或达到 limit），只有提前停止时才可能是被取消
+	finished := false
+	start := time.Now()
+	if runErr == nil {
+		stats := js.generator.run(active.ctx, active.plan, active.req.Offset, func(result models.GeneratedResult) bool {
+			batch = append(batch, result)
+			seq++
+			if result.IsExceeded {
+				exceeded++
+			}
+			if len(batch) >= jobResultBatchSize {
+				if runErr = flush(); runErr != nil {
+					return false
+				}
+			}
+			return active.req.Limit <= 0 || seq < int64(active.req.Limit)
+		})
+		finished = stats.done || (active.req.Limit > 0 && seq >= int64(active.req.Limit))
+		if runErr == nil {
+			runErr = flush()
+		}
+	}
+
+	status := models.JobSucceeded
+	message := ""
+	switch {
+	case runErr != nil:
+		status = models.JobFailed
+		message = runErr.Error()
+		if runes := []rune(message); len(runes) > maxJobErrorLength {
+			message = string(runes[:maxJobErrorLength])
+		}
+	case !finished && active.ctx.Err() != nil:
+		status = models.JobCanceled
+	}
+
+	// 只计入已写入的结果（写入失败时最后一批没有写入）
+	written := seq - int64(len(batch))
+	_, err = database.DB.Exec(`UPDATE generate_jobs SET status = ?, result_count = ?, exceeded_count = ?, error = ?,
+		finished_at = CURRENT_TIMESTAMP WHERE id = ?`, status, written, exceeded, message, id)
+	if err != nil {
+		log.Println("更新生成任务状态失败: " + err.Error())
+	}
+
+	// 成功和取消的任务记录已写入的结果，记录失败不影响任务状态
+	if status != models.JobFailed {
+		summary := active.plan.summary()
+		summary.ResultCount = written
+		summary.ExceededCount = exceeded
+		if err := js.history.Record(active.job.UserID, active.req, summary, time.Since(start)); err != nil {
+			log.Println(err)
+		}
+	}
+
+	js.mu.Lock()
+	delete(js.active, id)
+	js.mu.Unlock()
+	active.cancel()
+}
+
+// loadJob 从数据库读取任务（包含生成参数）
+func (js *JobService) loadJob(id, userID int64) (*models.GenerateJob, error) {
+	row := database.DB.QueryRow(`SELECT id, user_id, status, seed, total_count, expected_count, result_count,
+		exceeded_count, error, created_at, started_at, finished_at, request
+		FROM generate_jobs WHERE id = ? AND user_id = ?`, id, userID)
+
+	var request string
+	job, err := scanJob(row, &request)
+	if err != nil {
+		return nil, errors.New("生成任务不存在")
+	}
+	job.Request = &models.TemplateRequest{}
+	if err := json.Unmarshal([]byte(request), job.Request); err != nil {
+		return nil, errors.New("解析生成参数失败: " + err.Error())
+	}
+	return job, nil
+}
+
+// insertJobResults 批量写入结果，序号从 start 开始
+func insertJobResults(id, start int64, results []models.GeneratedResult) error {
+	args := make([]interface{}, 0, len(results)*3)
+	for i := range results {
+		data, err := json.Marshal(&results[i])
+		if err != nil {
+			return errors.New("序列化生成结果失败: " + err.Error())
+		}
+		args = append(args, id, start+int64(i), string(data))
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("(?, ?, ?), ", len(results)), ", ")
+	_, err := database.DB.Exec("INSERT INTO generate_job_results (job_id, seq, result) VALUES "+placeholders, args...)
+	if err != nil {
+		return errors.New("保存生成结果失败: " + err.Error())
+	}
+	return nil
+}
+
+// jobProgress 按已生成的结果数量计算任务进度（设置了约束规则时结果数量可能少于预计数量，完成时由调用方置为1）
+func jobProgress(count, expected int64) float64 {
+	if expected <= 0 || count >= expected {
+		return 1
+	}
+	return float64(count) / float64(expected)
+}
+
+// scanJob 读取一行任务记录，extra 为追加在固定字段之后的列
+func scanJob(row rowScanner, extra ...interface{}) (*models.GenerateJob, error) {
+	var job models.GenerateJob
+	var seed sql.NullInt64
+	var startedAt, finishedAt sql.NullString
+	dest := []interface{}{&job.ID, &job.UserID, &job.Status, &seed, &job.TotalCount, &job.ExpectedCount, &job.ResultCount,
+		&job.ExceededCount, &job.Error, &job.CreatedAt, &startedAt, &finishedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if seed.Valid {
+		job.Seed = &seed.Int64
+	}
+	job.StartedAt = startedAt.String
+	job.FinishedAt = finishedAt.String
+	job.Progress = jobProgress(job.ResultCount, job.ExpectedCount)
+	if job.Status == models.JobSucceeded {
+		job.Progress = 1
+	}
+	return &job, nil
+}
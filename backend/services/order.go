@@ -0,0 +1,202 @@
+package services
+
+import (
+	"fmt"
+	"sayhi/backend/models"
+)
+
+// arrangeAttempts 排列槽位时重新打乱单元顺序的最大次数
+const arrangeAttempts = 8
+
+// SlotOrder 随机生成时槽位顺序的约束
+// 开头、结尾和固定的槽位位置不变，其余槽位按单元（相邻位置组或单个槽位）打乱后依次填入空闲的槽位，
+// 相邻位置组不能跨越固定的槽位
+type SlotOrder struct {
+	base  []int   // 目标槽位 -> 来源槽位（-1 表示空闲，由打乱的单元填入）
+	units [][]int // 参与打乱的单元，每个单元是一组按顺序相邻的来源槽位
+	runs  []slotRun
+}
+
+// slotRun 一段连续的空闲槽位
+type slotRun struct {
+	start  int
+	length int
+}
+
+// CompilePositionOrder 将位置顺序约束编译为基于槽位的排列规则
+// 同一位置在模板中出现多次时，按出现顺序包含它的全部槽位；没有任何约束时返回 nil
+func CompilePositionOrder(order *models.PositionOrder, positionKeys []string) (*SlotOrder, error) {
+	if order == nil || len(order.Start)+len(order.End)+len(order.Fixed)+len(order.Groups) == 0 {
+		return nil, nil
+	}
+
+	slotsOf := make(map[string][]int, len(positionKeys))
+	for slot, key := range positionKeys {
+		slotsOf[key] = append(slotsOf[key], slot)
+	}
+
+	n := len(positionKeys)
+	used := make([]bool, n)
+	expand := func(keys []string) ([]int, error) {
+		var slots []int
+		for _, key := range keys {
+			keySlots, exists := slotsOf[key]
+			if !exists {
+				return nil, fmt.Errorf("位置顺序中的位置 %s 不在模板中", key)
+			}
+			for _, slot := range keySlots {
+				if used[slot] {
+					return nil, fmt.Errorf("位置 %s 在位置顺序中重复出现", key)
+				}
+				used[slot] = true
+			}
+			slots = append(slots, keySlots...)
+		}
+		return slots, nil
+	}
+
+	so := &SlotOrder{base: make([]int, n)}
+	for i := range so.base {
+		so.base[i] = -1
+	}
+
+	start, err := expand(order.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := expand(order.End)
+	if err != nil {
+		return nil, err
+	}
+	if len(start)+len(end) > n {
+		return nil, fmt.Errorf("开头和结尾的位置数量超过模板中的位置数量")
+	}
+	for i, slot := range start {
+		so.base[i] = slot
+	}
+	for i, slot := range end {
+		so.base[n-len(end)+i] = slot
+	}
+
+	fixed, err := expand(order.Fixed)
+	if err != nil {
+		return nil, err
+	}
+	for _, slot := range fixed {
+		if so.base[slot] != -1 {
+			return nil, fmt.Errorf("固定的位置 %s 与开头或结尾的位置冲突", positionKeys[slot])
+		}
+		so.base[slot] = slot
+	}
+
+	for _, group := range order.Groups {
+		slots, err := expand(group)
+		if err != nil {
+			return nil, err
+		}
+		so.units = append(so.units, slots)
+	}
+	for slot := range positionKeys {
+		if !used[slot] {
+			so.units = append(so.units, []int{slot})
+		}
+	}
+
+	for slot := 0; slot < n; slot++ {
+		if so.base[slot] != -1 {
+			continue
+		}
+		if len(so.runs) > 0 {
+			last := &so.runs[len(so.runs)-1]
+			if last.start+last.length == slot {
+				last.length++
+				continue
+			}
+		}
+		so.runs = append(so.runs, slotRun{start: slot, length: 1})
+	}
+
+	// 按原有顺序检查相邻位置组能否放入空闲的槽位
+	identity := make([]int, len(so.units))
+	for i := range identity {
+		identity[i] = i
+	}
+	if !so.fill(identity, make([]bool, len(so.units)), make([]int, n), 0, 0) {
+		return nil, fmt.Errorf("相邻位置组无法放入固定位置之间的空闲位置")
+	}
+
+	return so, nil
+}
+
+// Arrange 按种子和组合下标确定性地排列槽位，结果写入 perm（目标槽位 -> 来源槽位）
+// 单元的顺序用 ShuffleAt 打乱后依次填入，相邻位置组放不下时重新打乱（最多 arrangeAttempts 次，
+// 使各种可行的排列出现的机会大致相同），仍然放不下时回溯找到可行的排列。
+// 同一种子、同一组合下标总是得到相同的排列
+func (so *SlotOrder) Arrange(seed, index int64, perm []int) {
+	order := make([]int, len(so.units))
+	for i := range order {
+		order[i] = i
+	}
+	swap := func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	}
+
+	copy(perm, so.base)
+	for attempt := int64(0); attempt < arrangeAttempts; attempt++ {
+		ShuffleAt(seed+attempt, index, len(order), swap)
+		if so.place(order, perm) {
+			return
+		}
+	}
+	so.fill(order, make([]bool, len(so.units)), perm, 0, 0)
+}
+
+// place 按 order 的顺序将单元依次填入空闲的槽位，某个单元放不下时返回 false
+func (so *SlotOrder) place(order []int, perm []int) bool {
+	run, offset := 0, 0
+	for _, u := range order {
+		unit := so.units[u]
+		for run < len(so.runs) && offset == so.runs[run].length {
+			run, offset = run+1, 0
+		}
+		if run == len(so.runs) || len(unit) > so.runs[run].length-offset {
+			return false
+		}
+		for i, slot := range unit {
+			perm[so.runs[run].start+offset+i] = slot
+		}
+		offset += len(unit)
+	}
+	return true
+}
+
+// fill 按 order 的顺序依次将单元填入空闲的槽位，放不下时回溯
+// 同一位置已经尝试过的单元长度不再重复尝试（能否填满只取决于剩余单元的长度）
+func (so *SlotOrder) fill(order []int, placed []bool, perm []int, run, offset int) bool {
+	if run == len(so.runs) {
+		return true
+	}
+	r := so.runs[run]
+	if offset == r.length {
+		return so.fill(order, placed, perm, run+1, 0)
+	}
+
+	tried := make(map[int]bool)
+	for _, u := range order {
+		unit := so.units[u]
+		if placed[u] || len(unit) > r.length-offset || tried[len(unit)] {
+			continue
+		}
+		tried[len(unit)] = true
+
+		placed[u] = true
+		for i, slot := range unit {
+			perm[r.start+offset+i] = slot
+		}
+		if so.fill(order, placed, perm, run, offset+len(unit)) {
+			return true
+		}
+		placed[u] = false
+	}
+	return false
+}
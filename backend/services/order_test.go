@@ -0,0 +1,121 @@
+package services
+
+import (
+	"sayhi/backend/models"
+	"testing"
+)
+
+func TestCompilePositionOrderErrors(t *testing.T) {
+	keys := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		name  string
+		order *models.PositionOrder
+	}{
+		{name: "位置不在模板中", order: &models.PositionOrder{Start: []string{"z"}}},
+		{name: "位置重复", order: &models.PositionOrder{Start: []string{"a"}, End: []string{"a"}}},
+		{name: "开头和结尾过多", order: &models.PositionOrder{Start: []string{"a", "b", "c"}, End: []string{"d", "e", "a"}}},
+		{name: "固定位置与开头冲突", order: &models.PositionOrder{Start: []string{"b"}, Fixed: []string{"a"}}},
+		// c 固定在第3个槽位，两侧各只有2个空闲槽位，放不下3个位置的组
+		{name: "相邻组放不下", order: &models.PositionOrder{Fixed: []string{"c"}, Groups: [][]string{{"a", "b", "d"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CompilePositionOrder(tt.order, keys); err == nil {
+				t.Errorf("期望返回错误")
+			}
+		})
+	}
+
+	if so, err := CompilePositionOrder(&models.PositionOrder{}, keys); so != nil || err != nil {
+		t.Errorf("没有约束时应返回 nil, nil，得到 %v, %v", so, err)
+	}
+}
+
+func TestSlotOrderArrange(t *testing.T) {
+	keys := []string{"a", "b", "c", "d", "e", "f", "g"}
+	tests := []struct {
+		name  string
+		order *models.PositionOrder
+	}{
+		{name: "开头和结尾", order: &models.PositionOrder{Start: []string{"b", "a"}, End: []string{"g"}}},
+		{name: "固定位置", order: &models.PositionOrder{Fixed: []string{"d"}}},
+		{name: "相邻组", order: &models.PositionOrder{Groups: [][]string{{"e", "c"}, {"a", "f", "b"}}}},
+		{name: "固定位置之间的相邻组", order: &models.PositionOrder{Fixed: []string{"c"}, Groups: [][]string{{"d", "e", "f"}, {"a", "b"}}}},
+		{name: "全部约束", order: &models.PositionOrder{Start: []string{"a"}, End: []string{"g"}, Fixed: []string{"d"}, Groups: [][]string{{"b", "c"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			so, err := CompilePositionOrder(tt.order, keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			perm := make([]int, len(keys))
+			arrangements := make(map[string]bool)
+			for index := int64(0); index < 200; index++ {
+				so.Arrange(11, index, perm)
+				checkArrangement(t, tt.order, keys, perm)
+
+				again := make([]int, len(keys))
+				so.Arrange(11, index, again)
+				for i := range perm {
+					if perm[i] != again[i] {
+						t.Fatalf("同一种子和下标得到不同的排列 %v、%v", perm, again)
+					}
+				}
+
+				arranged := ""
+				for _, source := range perm {
+					arranged += keys[source]
+				}
+				arrangements[arranged] = true
+			}
+			if len(arrangements) < 2 {
+				t.Errorf("200 个组合只得到 %d 种排列", len(arrangements))
+			}
+		})
+	}
+}
+
+// checkArrangement 检查排列是否满足位置顺序约束
+func checkArrangement(t *testing.T, order *models.PositionOrder, keys []string, perm []int) {
+	t.Helper()
+	at := make(map[string]int, len(keys))
+	seen := make([]bool, len(keys))
+	for target, source := range perm {
+		if source < 0 || source >= len(keys) || seen[source] {
+			t.Fatalf("%v 不是排列", perm)
+		}
+		seen[source] = true
+		at[keys[source]] = target
+	}
+
+	for i, key := range order.Start {
+		if at[key] != i {
+			t.Errorf("%v: 开头的位置 %s 在第 %d 个槽位", perm, key, at[key])
+		}
+	}
+	for i, key := range order.End {
+		if want := len(keys) - len(order.End) + i; at[key] != want {
+			t.Errorf("%v: 结尾的位置 %s 在第 %d 个槽位，期望第 %d 个", perm, key, at[key], want)
+		}
+	}
+	for _, key := range order.Fixed {
+		slot := -1
+		for i, k := range keys {
+			if k == key {
+				slot = i
+			}
+		}
+		if at[key] != slot {
+			t.Errorf("%v: 固定的位置 %s 在第 %d 个槽位，期望第 %d 个", perm, key, at[key], slot)
+		}
+	}
+	for _, group := range order.Groups {
+		for i := 1; i < len(group); i++ {
+			if at[group[i]] != at[group[0]]+i {
+				t.Errorf("%v: 相邻组 %v 没有按顺序相邻", perm, group)
+			}
+		}
+	}
+}
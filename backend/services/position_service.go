@@ -1,7 +1,10 @@
 package services
 
 import (
+	"errors"
 	"sayhi/backend/database"
+	"sayhi/backend/models"
+	"sayhi/backend/utils"
 )
 
 // PositionService 位置值服务（使用数据库存储）
@@ -35,6 +38,50 @@ func (ps *PositionService) GetAllPositions() map[string][]string {
 	return result
 }
 
+// GetAllPositionEncodings 获取所有位置值检测到的编码，顺序与 GetAllPositions 一致
+func (ps *PositionService) GetAllPositionEncodings() map[string][]models.EncodingType {
+	result := make(map[string][]models.EncodingType)
+
+	rows, err := database.DB.Query("SELECT position, value, encoding FROM position_values ORDER BY position, sort_order")
+	if err != nil {
+		return result
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var position, value string
+		var encoding models.EncodingType
+		if err := rows.Scan(&position, &value, &encoding); err != nil {
+			continue
+		}
+		result[position] = append(result[position], storedEncoding(value, encoding))
+	}
+
+	return result
+}
+
+// GetAllPositionWeights 获取所有位置值的权重，顺序与 GetAllPositions 一致
+func (ps *PositionService) GetAllPositionWeights() map[string][]float64 {
+	result := make(map[string][]float64)
+
+	rows, err := database.DB.Query("SELECT position, weight FROM position_values ORDER BY position, sort_order")
+	if err != nil {
+		return result
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var position string
+		var weight float64
+		if err := rows.Scan(&position, &weight); err != nil {
+			continue
+		}
+		result[position] = append(result[position], weight)
+	}
+
+	return result
+}
+
 // GetPositionValues 获取指定位置的值
 func (ps *PositionService) GetPositionValues(position string) []string {
 	var values []string
@@ -57,7 +104,7 @@ func (ps *PositionService) GetPositionValues(position string) []string {
 }
 
 // AddPositionValue 添加位置值
-func (ps *PositionService) AddPositionValue(position string, value string) {
+func (ps *PositionService) AddPositionValue(position string, value string, weight float64) {
 	// 检查是否已存在
 	var count int
 	database.DB.QueryRow("SELECT COUNT(*) FROM position_values WHERE position = ? AND value = ?", position, value).Scan(&count)
@@ -69,12 +116,13 @@ func (ps *PositionService) AddPositionValue(position string, value string) {
 	var maxSort int
 	database.DB.QueryRow("SELECT COALESCE(MAX(sort_order), 0) FROM position_values WHERE position = ?", position).Scan(&maxSort)
 
-	// 插入新值
-	database.DB.Exec("INSERT INTO position_values (position, value, sort_order) VALUES (?, ?, ?)", position, value, maxSort+1)
+	// 插入新值（同时记录检测到的编码）
+	encoding, _ := utils.DetectMyanmarEncoding(value)
+	database.DB.Exec("INSERT INTO position_values (position, value, encoding, weight, sort_order) VALUES (?, ?, ?, ?, ?)", position, value, encoding, weight, maxSort+1)
 }
 
-// SetPositionValues 设置位置的所有值
-func (ps *PositionService) SetPositionValues(position string, values []string) {
+// SetPositionValues 设置位置的所有值，weights 与 values 一一对应（为空时均为默认权重）
+func (ps *PositionService) SetPositionValues(position string, values []string, weights []float64) {
 	// 开启事务
 	tx, err := database.DB.Begin()
 	if err != nil {
@@ -90,7 +138,8 @@ func (ps *PositionService) SetPositionValues(position string, values []string) {
 
 	// 插入新值
 	for i, value := range values {
-		_, err = tx.Exec("INSERT INTO position_values (position, value, sort_order) VALUES (?, ?, ?)", position, value, i+1)
+		encoding, _ := utils.DetectMyanmarEncoding(value)
+		_, err = tx.Exec("INSERT INTO position_values (position, value, encoding, weight, sort_order) VALUES (?, ?, ?, ?, ?)", position, value, encoding, weightAt(weights, i), i+1)
 		if err != nil {
 			return
 		}
@@ -107,5 +156,161 @@ func (ps *PositionService) DeletePositionValue(position string, value string) {
 
 // UpdatePositionValue 更新位置值
 func (ps *PositionService) UpdatePositionValue(position string, oldValue string, newValue string) {
-	database.DB.Exec("UPDATE position_values SET value = ? WHERE position = ? AND value = ?", newValue, position, oldValue)
+	encoding, _ := utils.DetectMyanmarEncoding(newValue)
+	database.DB.Exec("UPDATE position_values SET value = ?, encoding = ? WHERE position = ? AND value = ?", newValue, encoding, position, oldValue)
+}
+
+// GetDefinitions 获取所有位置定义（按排序顺序）
+func (ps *PositionService) GetDefinitions() []models.Position {
+	positions := []models.Position{}
+
+	rows, err := database.DB.Query(`SELECT p.id, p.name, p.label, p.description, p.sort_order, p.created_at, p.updated_at,
+		(SELECT COUNT(*) FROM position_values v WHERE v.position = p.name)
+		FROM positions p ORDER BY p.sort_order, p.id`)
+	if err != nil {
+		return positions
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var position models.Position
+		if err := rows.Scan(&position.ID, &position.Name, &position.Label, &position.Description, &position.SortOrder,
+			&position.CreatedAt, &position.UpdatedAt, &position.ValueCount); err != nil {
+			continue
+		}
+		positions = append(positions, position)
+	}
+
+	return positions
+}
+
+// GetDefinition 获取位置定义
+func (ps *PositionService) GetDefinition(name string) (*models.Position, error) {
+	var position models.Position
+	err := database.DB.QueryRow(`SELECT p.id, p.name, p.label, p.description, p.sort_order, p.created_at, p.updated_at,
+		(SELECT COUNT(*) FROM position_values v WHERE v.position = p.name)
+		FROM positions p WHERE p.name = ?`, name).
+		Scan(&position.ID, &position.Name, &position.Label, &position.Description, &position.SortOrder,
+			&position.CreatedAt, &position.UpdatedAt, &position.ValueCount)
+	if err != nil {
+		return nil, errors.New("位置不存在: " + name)
+	}
+
+	return &position, nil
+}
+
+// PositionExists 判断位置是否已定义
+func (ps *PositionService) PositionExists(name string) bool {
+	var count int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM positions WHERE name = ?", name).Scan(&count); err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// CreatePosition 创建位置
+// 未指定排序顺序时排在最后
+func (ps *PositionService) CreatePosition(req *models.PositionRequest) (*models.Position, error) {
+	if ps.PositionExists(req.Name) {
+		return nil, errors.New("位置已存在: " + req.Name)
+	}
+
+	sortOrder := req.SortOrder
+	if sortOrder <= 0 {
+		var maxSort int
+		database.DB.QueryRow("SELECT COALESCE(MAX(sort_order), 0) FROM positions").Scan(&maxSort)
+		sortOrder = maxSort + 1
+	}
+
+	_, err := database.DB.Exec("INSERT INTO positions (name, label, description, sort_order) VALUES (?, ?, ?, ?)",
+		req.Name, req.Label, req.Description, sortOrder)
+	if err != nil {
+		return nil, errors.New("创建位置失败: " + err.Error())
+	}
+
+	return ps.GetDefinition(req.Name)
+}
+
+// UpdatePosition 更新位置定义
+// 修改位置标识时同时更新该位置的所有值
+func (ps *PositionService) UpdatePosition(name string, req *models.PositionUpdateRequest) (*models.Position, error) {
+	current, err := ps.GetDefinition(name)
+	if err != nil {
+		return nil, err
+	}
+
+	newName := current.Name
+	if req.Name != "" && req.Name != name {
+		if ps.PositionExists(req.Name) {
+			return nil, errors.New("位置已存在: " + req.Name)
+		}
+		newName = req.Name
+	}
+	label := current.Label
+	if req.Label != "" {
+		label = req.Label
+	}
+	description := current.Description
+	if req.Description != "" {
+		description = req.Description
+	}
+	sortOrder := current.SortOrder
+	if req.SortOrder != nil {
+		sortOrder = *req.SortOrder
+	}
+
+	// 开启事务
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, errors.New("开启事务失败: " + err.Error())
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE positions SET name = ?, label = ?, description = ?, sort_order = ? WHERE id = ?",
+		newName, label, description, sortOrder, current.ID)
+	if err != nil {
+		return nil, errors.New("更新位置失败: " + err.Error())
+	}
+
+	if newName != name {
+		_, err = tx.Exec("UPDATE position_values SET position = ? WHERE position = ?", newName, name)
+		if err != nil {
+			return nil, errors.New("更新位置值失败: " + err.Error())
+		}
+	}
+
+	// 提交事务
+	if err = tx.Commit(); err != nil {
+		return nil, errors.New("提交事务失败: " + err.Error())
+	}
+
+	return ps.GetDefinition(newName)
+}
+
+// DeletePosition 删除位置及其所有值
+func (ps *PositionService) DeletePosition(name string) error {
+	if !ps.PositionExists(name) {
+		return errors.New("位置不存在: " + name)
+	}
+
+	// 开启事务
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return errors.New("开启事务失败: " + err.Error())
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec("DELETE FROM position_values WHERE position = ?", name); err != nil {
+		return errors.New("删除位置值失败: " + err.Error())
+	}
+	if _, err = tx.Exec("DELETE FROM positions WHERE name = ?", name); err != nil {
+		return errors.New("删除位置失败: " + err.Error())
+	}
+
+	// 提交事务
+	if err = tx.Commit(); err != nil {
+		return errors.New("提交事务失败: " + err.Error())
+	}
+
+	return nil
 }
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"sayhi/backend/database"
+	"sayhi/backend/models"
+)
+
+// RuleService 模板约束规则服务（使用数据库存储）
+type RuleService struct{}
+
+// NewRuleService 创建模板约束规则服务
+func NewRuleService() *RuleService {
+	return &RuleService{}
+}
+
+// GetRules 获取模板的约束规则，模板必须属于指定用户
+func (rs *RuleService) GetRules(templateID, userID int64) ([]models.ConstraintRule, error) {
+	if err := rs.checkTemplate(templateID, userID); err != nil {
+		return nil, err
+	}
+
+	return loadRules(database.DB, templateID)
+}
+
+// loadRules 读取模板的约束规则（不检查模板归属），q 为 database.DB 或事务
+func loadRules(q queryer, templateID int64) ([]models.ConstraintRule, error) {
+	rows, err := q.Query(`SELECT id, template_id, position, value, rule_type, target_position, target_value
+		FROM template_rules WHERE template_id = ? ORDER BY id`, templateID)
+	if err != nil {
+		return nil, errors.New("查询约束规则失败: " + err.Error())
+	}
+	defer rows.Close()
+
+	rules := []models.ConstraintRule{}
+	for rows.Next() {
+		var rule models.ConstraintRule
+		if err := rows.Scan(&rule.ID, &rule.TemplateID, &rule.Position, &rule.Value, &rule.Type,
+			&rule.TargetPosition, &rule.TargetValue); err != nil {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// replaceRules 在事务中删除模板原有的约束规则并插入新规则
+func replaceRules(tx *sql.Tx, templateID int64, rules []models.ConstraintRule) error {
+	if _, err := tx.Exec("DELETE FROM template_rules WHERE template_id = ?", templateID); err != nil {
+		return errors.New("删除旧规则失败: " + err.Error())
+	}
+
+	for _, rule := range rules {
+		_, err := tx.Exec(`INSERT INTO template_rules (template_id, position, value, rule_type, target_position, target_value)
+			VALUES (?, ?, ?, ?, ?, ?)`, templateID, rule.Position, rule.Value, rule.Type, rule.TargetPosition, rule.TargetValue)
+		if err != nil {
+			return errors.New("插入规则失败: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// checkTemplate 检查模板是否存在且属于指定用户
+func (rs *RuleService) checkTemplate(templateID, userID int64) error {
+	var count int
+	err := database.DB.QueryRow("SELECT COUNT(*) FROM templates WHERE id = ? AND user_id = ?", templateID, userID).Scan(&count)
+	if err != nil {
+		return errors.New("查询模板失败: " + err.Error())
+	}
+	if count == 0 {
+		return errors.New("模板不存在")
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"hash/fnv"
+	"math"
+	"sayhi/backend/models"
+	"sort"
+)
+
+const (
+	// MaxEditDistance 编辑距离过滤允许的最大最小距离
+	MaxEditDistance = 20
+	// DefaultNGram Jaccard 距离默认的 n-gram 长度（按字符）
+	DefaultNGram = 3
+	// MaxNGram Jaccard 距离允许的最大 n-gram 长度
+	MaxNGram = 10
+
+	minHashCount   = 128  // MinHash 签名长度
+	lshMinRecall   = 0.95 // 相似度恰好等于阈值的一对结果被 LSH 选为候选的最低概率
+	lshRowsMaximum = 16
+)
+
+// similarityFilter 近似重复过滤器：依次判断每条结果，与已接受的结果都足够不同时接受
+type similarityFilter interface {
+	// Accept 判断内容与已接受的结果是否都足够不同，是则记录并返回 true
+	Accept(content string) bool
+}
+
+// newSimilarityFilter 按请求创建近似重复过滤器，未设置时返回 nil
+func newSimilarityFilter(config *models.SimilarityFilter) similarityFilter {
+	if config == nil {
+		return nil
+	}
+	switch config.Method {
+	case models.SimilarityEdit:
+		return newEditFilter(int(config.MinDistance))
+	case models.SimilarityJaccard:
+		n := config.NGram
+		if n <= 0 {
+			n = DefaultNGram
+		}
+		return newJaccardFilter(n, 1-config.MinDistance)
+	default:
+		return nil
+	}
+}
+
+// editFilter 编辑距离过滤：拒绝与已接受的结果编辑距离小于 minDistance 的结果
+// 使用分段索引（PassJoin）查找候选：编辑距离不超过 d 时，把已接受的结果平均分成 d+1 段，
+// 至少有一段未被修改，并且出现在新结果中偏移不超过 d 的位置。因此按（长度、段号、段内容）建立索引，
+// 只需对命中索引的结果计算编辑距离，不会漏掉任何相似的结果
+type editFilter struct {
+	d        int // 视为相似的最大编辑距离（minDistance - 1）
+	accepted [][]rune
+	index    map[editSegmentKey][]int
+	visited  []int // 本次查找中已检查过的结果，值为查找序号
+	round    int
+}
+
+// editSegmentKey 分段索引的键
+type editSegmentKey struct {
+	length  int
+	segment int
+	text    string
+}
+
+func newEditFilter(minDistance int) *editFilter {
+	return &editFilter{
+		d:     minDistance - 1,
+		index: make(map[editSegmentKey][]int),
+	}
+}
+
+func (f *editFilter) Accept(content string) bool {
+	t := []rune(content)
+	m := len(t)
+	f.round++
+
+	for length := m - f.d; length <= m+f.d; length++ {
+		if length < 0 {
+			continue
+		}
+		for segment := 0; segment <= f.d; segment++ {
+			start, size := editSegment(length, f.d+1, segment)
+			for p := start - f.d; p <= start+f.d; p++ {
+				if p < 0 || p+size > m {
+					continue
+				}
+				key := editSegmentKey{length: length, segment: segment, text: string(t[p : p+size])}
+				for _, id := range f.index[key] {
+					if f.visited[id] == f.round {
+						continue
+					}
+					f.visited[id] = f.round
+					if withinEditDistance(f.accepted[id], t, f.d) {
+						return false
+					}
+				}
+			}
+		}
+	}
+
+	id := len(f.accepted)
+	f.accepted = append(f.accepted, t)
+	f.visited = append(f.visited, 0)
+	for segment := 0; segment <= f.d; segment++ {
+		start, size := editSegment(m, f.d+1, segment)
+		key := editSegmentKey{length: m, segment: segment, text: string(t[start : start+size])}
+		f.index[key] = append(f.index[key], id)
+	}
+	return true
+}
+
+// editSegment 将长度为 length 的字符串平均分成 parts 段，返回第 segment 段的起始位置和长度
+// 前面的段较短，长度不足 parts 时部分段为空
+func editSegment(length, parts, segment int) (start, size int) {
+	short := length / parts
+	longCount := length % parts
+	shortCount := parts - longCount
+	if segment < shortCount {
+		return segment * short, short
+	}
+	return shortCount*short + (segment-shortCount)*(short+1), short + 1
+}
+
+// withinEditDistance 判断两个字符串的编辑距离（Levenshtein 距离）是否不超过 d
+// 只计算对角线两侧 d 以内的单元格，某一行全部超过 d 时提前结束
+func withinEditDistance(a, b []rune, d int) bool {
+	n, m := len(a), len(b)
+	if n-m > d || m-n > d {
+		return false
+	}
+
+	inf := d + 1
+	prev := make([]int, m+1)
+	cur := make([]int, m+1)
+	for j := range prev {
+		prev[j] = inf
+		if j <= d {
+			prev[j] = j
+		}
+	}
+
+	for i := 1; i <= n; i++ {
+		lo, hi := i-d, i+d
+		if lo < 1 {
+			lo = 1
+		}
+		if hi > m {
+			hi = m
+		}
+
+		cur[lo-1] = inf
+		if lo == 1 && i <= d {
+			cur[0] = i
+		}
+		rowMin := cur[lo-1]
+		for j := lo; j <= hi; j++ {
+			v := prev[j-1]
+			if a[i-1] != b[j-1] {
+				v++
+			}
+			if prev[j]+1 < v {
+				v = prev[j] + 1
+			}
+			if cur[j-1]+1 < v {
+				v = cur[j-1] + 1
+			}
+			if v > inf {
+				v = inf
+			}
+			cur[j] = v
+			if v < rowMin {
+				rowMin = v
+			}
+		}
+		if hi < m {
+			cur[hi+1] = inf
+		}
+		if rowMin > d {
+			return false
+		}
+		prev, cur = cur, prev
+	}
+	return prev[m] <= d
+}
+
+// jaccardFilter Jaccard 距离过滤：拒绝与已接受的结果的字符 n-gram 集合相似度超过 maxSimilarity 的结果
+// 使用 MinHash 签名和 LSH 分桶查找候选，只对同桶的结果计算准确的 Jaccard 相似度。
+// 分桶参数按阈值选取，使相似度恰好等于阈值的一对结果至少有 95% 的概率被选为候选（更相似时概率更高），
+// 因此极少数相似的结果可能漏过，但不会误删足够不同的结果
+type jaccardFilter struct {
+	n             int
+	maxSimilarity float64
+	bands, rows   int
+	seeds         [minHashCount]uint64
+	buckets       map[lshBucketKey][]int
+	shingles      [][]uint64 // 已接受结果的 n-gram 摘要（已排序、去重）
+	visited       []int
+	round         int
+}
+
+// lshBucketKey LSH 分桶的键
+type lshBucketKey struct {
+	band int
+	hash uint64
+}
+
+func newJaccardFilter(n int, maxSimilarity float64) *jaccardFilter {
+	f := &jaccardFilter{
+		n:             n,
+		maxSimilarity: maxSimilarity,
+		buckets:       make(map[lshBucketKey][]int),
+	}
+	f.bands, f.rows = lshParams(maxSimilarity)
+
+	state := uint64(0x5eed)
+	for i := range f.seeds {
+		state, f.seeds[i] = splitMix64(state)
+	}
+	return f
+}
+
+func (f *jaccardFilter) Accept(content string) bool {
+	shingles := f.shingle(content)
+	signature := f.signature(shingles)
+	f.round++
+
+	keys := make([]lshBucketKey, f.bands)
+	for band := range keys {
+		keys[band] = lshBucketKey{band: band, hash: bandHash(signature[band*f.rows : (band+1)*f.rows])}
+		for _, id := range f.buckets[keys[band]] {
+			if f.visited[id] == f.round {
+				continue
+			}
+			f.visited[id] = f.round
+			if jaccardSimilarity(f.shingles[id], shingles) > f.maxSimilarity {
+				return false
+			}
+		}
+	}
+
+	id := len(f.shingles)
+	f.shingles = append(f.shingles, shingles)
+	f.visited = append(f.visited, 0)
+	for _, key := range keys {
+		f.buckets[key] = append(f.buckets[key], id)
+	}
+	return true
+}
+
+// shingle 返回内容的字符 n-gram 摘要（已排序、去重），内容短于 n 个字符时整体作为一个 n-gram
+func (f *jaccardFilter) shingle(content string) []uint64 {
+	runes := []rune(content)
+	count := len(runes) - f.n + 1
+	if count < 1 {
+		count = 1
+	}
+
+	hashes := make([]uint64, 0, count)
+	for i := 0; i < count; i++ {
+		end := i + f.n
+		if end > len(runes) {
+			end = len(runes)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(string(runes[i:end])))
+		hashes = append(hashes, h.Sum64())
+	}
+
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	unique := hashes[:1]
+	for _, h := range hashes[1:] {
+		if h != unique[len(unique)-1] {
+			unique = append(unique, h)
+		}
+	}
+	return unique
+}
+
+// signature 计算 MinHash 签名：每个哈希函数下 n-gram 摘要的最小值
+func (f *jaccardFilter) signature(shingles []uint64) []uint64 {
+	signature := make([]uint64, minHashCount)
+	for i, seed := range f.seeds {
+		min := uint64(math.MaxUint64)
+		for _, s := range shingles {
+			if _, h := splitMix64(s ^ seed); h < min {
+				min = h
+			}
+		}
+		signature[i] = min
+	}
+	return signature
+}
+
+// lshParams 按相似度阈值选择分桶参数：每个桶包含 rows 个签名值，共 bands 个桶（bands*rows 不超过签名长度）。
+// 相似度为 s 的一对结果至少落入同一个桶的概率为 1-(1-s^rows)^bands，
+// 在该概率在阈值处不低于 lshMinRecall 的前提下取最大的 rows，以减少需要精确比较的候选
+func lshParams(threshold float64) (bands, rows int) {
+	bands, rows = minHashCount, 1
+	for r := 2; r <= lshRowsMaximum; r++ {
+		b := minHashCount / r
+		if 1-math.Pow(1-math.Pow(threshold, float64(r)), float64(b)) < lshMinRecall {
+			break
+		}
+		bands, rows = b, r
+	}
+	return bands, rows
+}
+
+// bandHash 计算一个桶内签名值的哈希
+func bandHash(values []uint64) uint64 {
+	h := uint64(0xcbf29ce484222325)
+	for _, v := range values {
+		_, h = splitMix64(h ^ v)
+	}
+	return h
+}
+
+// jaccardSimilarity 计算两个已排序、去重的集合的 Jaccard 相似度
+func jaccardSimilarity(a, b []uint64) float64 {
+	intersection := 0
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			intersection++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	return float64(intersection) / float64(union)
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// levenshtein 计算编辑距离（完整的动态规划，作为对照）
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			v := prev[j-1]
+			if a[i-1] != b[j-1] {
+				v++
+			}
+			if prev[j]+1 < v {
+				v = prev[j] + 1
+			}
+			if cur[j-1]+1 < v {
+				v = cur[j-1] + 1
+			}
+			cur[j] = v
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestWithinEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		d    int
+		want bool
+	}{
+		{"", "", 0, true},
+		{"abc", "abc", 0, true},
+		{"abc", "abd", 0, false},
+		{"abc", "abd", 1, true},
+		{"kitten", "sitting", 2, false},
+		{"kitten", "sitting", 3, true},
+		{"", "abc", 2, false},
+		{"", "abc", 3, true},
+		{"မင်္ဂလာ", "မင်ဂလာ", 1, true},
+	}
+	for _, tt := range tests {
+		if got := withinEditDistance([]rune(tt.a), []rune(tt.b), tt.d); got != tt.want {
+			t.Errorf("withinEditDistance(%q, %q, %d) = %v，期望 %v", tt.a, tt.b, tt.d, got, tt.want)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		a, b := randomText(rng, 12), randomText(rng, 12)
+		distance := levenshtein(a, b)
+		for d := 0; d <= 6; d++ {
+			if got := withinEditDistance(a, b, d); got != (distance <= d) {
+				t.Fatalf("withinEditDistance(%q, %q, %d) = %v，编辑距离为 %d", string(a), string(b), d, got, distance)
+			}
+		}
+	}
+}
+
+// randomText 返回由少量字母组成的随机字符串，使相似的字符串足够多
+func randomText(rng *rand.Rand, maxLen int) []rune {
+	text := make([]rune, rng.Intn(maxLen+1))
+	for i := range text {
+		text[i] = rune('a' + rng.Intn(3))
+	}
+	return text
+}
+
+func TestEditSegment(t *testing.T) {
+	for length := 0; length <= 20; length++ {
+		for parts := 1; parts <= 6; parts++ {
+			next := 0
+			for segment := 0; segment < parts; segment++ {
+				start, size := editSegment(length, parts, segment)
+				if start != next {
+					t.Fatalf("editSegment(%d, %d, %d) 起始位置 %d，期望 %d", length, parts, segment, start, next)
+				}
+				next = start + size
+			}
+			if next != length {
+				t.Fatalf("editSegment(%d, %d) 各段总长 %d", length, parts, next)
+			}
+		}
+	}
+}
+
+// 分段索引的结果必须与逐个比较完全一致
+func TestEditFilterMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, minDistance := range []int{1, 2, 3, 5} {
+		f := newEditFilter(minDistance)
+		var accepted [][]rune
+		for i := 0; i < 500; i++ {
+			text := randomText(rng, 10)
+			want := true
+			for _, a := range accepted {
+				if levenshtein(a, text) < minDistance {
+					want = false
+					break
+				}
+			}
+			if got := f.Accept(string(text)); got != want {
+				t.Fatalf("minDistance=%d: Accept(%q) = %v，期望 %v", minDistance, string(text), got, want)
+			}
+			if want {
+				accepted = append(accepted, text)
+			}
+		}
+	}
+}
+
+func TestJaccardFilter(t *testing.T) {
+	f := newJaccardFilter(3, 0.5)
+	steps := []struct {
+		content string
+		want    bool
+	}{
+		{"Hello, your order 12345 has shipped", true},
+		{"Hello, your order 12345 has shipped", false},
+		{"Hello, your order 12346 has shipped", false},
+		{"Completely different text about weather", true},
+		{"A", true},
+		{"A", false},
+	}
+	for _, step := range steps {
+		if got := f.Accept(step.content); got != step.want {
+			t.Errorf("Accept(%q) = %v，期望 %v", step.content, got, step.want)
+		}
+	}
+}
+
+// Jaccard 过滤不会误删足够不同的结果：接受的结果与已接受的结果相似度都不超过阈值，
+// 被拒绝的结果至少与一个已接受的结果相似度超过阈值
+func TestJaccardFilterNeverRejectsDistinct(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	f := newJaccardFilter(2, 0.6)
+	var accepted [][]uint64
+	for i := 0; i < 300; i++ {
+		content := string(randomText(rng, 15))
+		shingles := f.shingle(content)
+		similar := false
+		for _, a := range accepted {
+			if jaccardSimilarity(a, shingles) > 0.6 {
+				similar = true
+				break
+			}
+		}
+		if f.Accept(content) {
+			if similar {
+				// LSH 可能漏掉相似的结果，但只允许少数
+				continue
+			}
+			accepted = append(accepted, shingles)
+		} else if !similar {
+			t.Fatalf("Accept(%q) 拒绝了与已接受的结果都足够不同的内容", content)
+		}
+	}
+}
+
+func TestJaccardSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []uint64
+		want float64
+	}{
+		{[]uint64{1, 2, 3}, []uint64{1, 2, 3}, 1},
+		{[]uint64{1, 2}, []uint64{3, 4}, 0},
+		{[]uint64{1, 2, 3}, []uint64{2, 3, 4}, 0.5},
+		{[]uint64{1}, []uint64{1, 2, 3, 4}, 0.25},
+	}
+	for _, tt := range tests {
+		if got := jaccardSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("jaccardSimilarity(%v, %v) = %v，期望 %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLSHParamsRecall(t *testing.T) {
+	for _, threshold := range []float64{0.1, 0.3, 0.5, 0.7, 0.9, 0.99} {
+		bands, rows := lshParams(threshold)
+		if bands*rows > minHashCount || rows < 1 || rows > lshRowsMaximum {
+			t.Fatalf("lshParams(%v) = %d, %d 超出签名长度", threshold, bands, rows)
+		}
+		recall := 1 - math.Pow(1-math.Pow(threshold, float64(rows)), float64(bands))
+		if recall < lshMinRecall {
+			t.Errorf("lshParams(%v) = %d, %d，阈值处的召回率 %v 低于 %v", threshold, bands, rows, recall, lshMinRecall)
+		}
+	}
+}
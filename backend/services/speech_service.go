@@ -4,6 +4,7 @@ import (
 	"errors"
 	"sayhi/backend/database"
 	"sayhi/backend/models"
+	"sayhi/backend/utils"
 )
 
 // SpeechService 话术服务（使用数据库存储）
@@ -48,7 +49,8 @@ func (ss *SpeechService) CreateGroup(req *models.SpeechGroupRequest) (*models.Sp
 
 	// 插入话术内容
 	for i, speech := range req.Speeches {
-		_, err = tx.Exec("INSERT INTO speeches (group_id, content, sort_order) VALUES (?, ?, ?)", groupID, speech, i+1)
+		encoding, _ := utils.DetectMyanmarEncoding(speech)
+		_, err = tx.Exec("INSERT INTO speeches (group_id, content, encoding, weight, sort_order) VALUES (?, ?, ?, ?, ?)", groupID, speech, encoding, weightAt(req.Weights, i), i+1)
 		if err != nil {
 			return nil, errors.New("插入话术失败: " + err.Error())
 		}
@@ -65,8 +67,14 @@ func (ss *SpeechService) CreateGroup(req *models.SpeechGroupRequest) (*models.Sp
 		Name:        req.Name,
 		Description: req.Description,
 		Speeches:    make([]string, len(req.Speeches)),
+		Encodings:   make([]models.EncodingType, len(req.Speeches)),
+		Weights:     make([]float64, len(req.Speeches)),
 	}
 	copy(group.Speeches, req.Speeches)
+	for i, speech := range req.Speeches {
+		group.Encodings[i], _ = utils.DetectMyanmarEncoding(speech)
+		group.Weights[i] = weightAt(req.Weights, i)
+	}
 
 	return group, nil
 }
@@ -81,21 +89,29 @@ func (ss *SpeechService) GetGroup(id int64) (*models.SpeechGroup, error) {
 	}
 
 	// 获取话术内容
-	rows, err := database.DB.Query("SELECT content FROM speeches WHERE group_id = ? ORDER BY sort_order", id)
+	rows, err := database.DB.Query("SELECT content, encoding, weight FROM speeches WHERE group_id = ? ORDER BY sort_order", id)
 	if err != nil {
 		return nil, errors.New("查询话术失败: " + err.Error())
 	}
 	defer rows.Close()
 
 	var speeches []string
+	var encodings []models.EncodingType
+	var weights []float64
 	for rows.Next() {
 		var content string
-		if err := rows.Scan(&content); err != nil {
+		var encoding models.EncodingType
+		var weight float64
+		if err := rows.Scan(&content, &encoding, &weight); err != nil {
 			continue
 		}
 		speeches = append(speeches, content)
+		encodings = append(encodings, storedEncoding(content, encoding))
+		weights = append(weights, weight)
 	}
 	group.Speeches = speeches
+	group.Encodings = encodings
+	group.Weights = weights
 
 	return &group, nil
 }
@@ -110,21 +126,29 @@ func (ss *SpeechService) GetGroupByName(name string) (*models.SpeechGroup, error
 	}
 
 	// 获取话术内容
-	rows, err := database.DB.Query("SELECT content FROM speeches WHERE group_id = ? ORDER BY sort_order", group.ID)
+	rows, err := database.DB.Query("SELECT content, encoding, weight FROM speeches WHERE group_id = ? ORDER BY sort_order", group.ID)
 	if err != nil {
 		return nil, errors.New("查询话术失败: " + err.Error())
 	}
 	defer rows.Close()
 
 	var speeches []string
+	var encodings []models.EncodingType
+	var weights []float64
 	for rows.Next() {
 		var content string
-		if err := rows.Scan(&content); err != nil {
+		var encoding models.EncodingType
+		var weight float64
+		if err := rows.Scan(&content, &encoding, &weight); err != nil {
 			continue
 		}
 		speeches = append(speeches, content)
+		encodings = append(encodings, storedEncoding(content, encoding))
+		weights = append(weights, weight)
 	}
 	group.Speeches = speeches
+	group.Encodings = encodings
+	group.Weights = weights
 
 	return &group, nil
 }
@@ -145,17 +169,25 @@ func (ss *SpeechService) GetAllGroups() []models.SpeechGroup {
 		}
 
 		// 获取话术内容
-		speechRows, err := database.DB.Query("SELECT content FROM speeches WHERE group_id = ? ORDER BY sort_order", group.ID)
+		speechRows, err := database.DB.Query("SELECT content, encoding, weight FROM speeches WHERE group_id = ? ORDER BY sort_order", group.ID)
 		if err == nil {
 			var speeches []string
+			var encodings []models.EncodingType
+			var weights []float64
 			for speechRows.Next() {
 				var content string
-				if err := speechRows.Scan(&content); err != nil {
+				var encoding models.EncodingType
+				var weight float64
+				if err := speechRows.Scan(&content, &encoding, &weight); err != nil {
 					continue
 				}
 				speeches = append(speeches, content)
+				encodings = append(encodings, storedEncoding(content, encoding))
+				weights = append(weights, weight)
 			}
 			group.Speeches = speeches
+			group.Encodings = encodings
+			group.Weights = weights
 			speechRows.Close()
 		}
 
@@ -217,7 +249,8 @@ func (ss *SpeechService) UpdateGroup(id int64, req *models.SpeechGroupUpdateRequ
 
 		// 插入新话术
 		for i, speech := range req.Speeches {
-			_, err = tx.Exec("INSERT INTO speeches (group_id, content, sort_order) VALUES (?, ?, ?)", id, speech, i+1)
+			encoding, _ := utils.DetectMyanmarEncoding(speech)
+			_, err = tx.Exec("INSERT INTO speeches (group_id, content, encoding, weight, sort_order) VALUES (?, ?, ?, ?, ?)", id, speech, encoding, weightAt(req.Weights, i), i+1)
 			if err != nil {
 				return nil, errors.New("插入话术失败: " + err.Error())
 			}
@@ -256,11 +289,50 @@ func (ss *SpeechService) DeleteGroup(id int64) error {
 
 // GetGroupSpeeches 获取话术组的所有话术
 func (ss *SpeechService) GetGroupSpeeches(nameOrID string) ([]string, error) {
+	group, err := ss.findGroup(nameOrID)
+	if err != nil {
+		return nil, err
+	}
+
+	return group.Speeches, nil
+}
+
+// GetGroupWeights 获取话术组每条话术的权重，与 GetGroupSpeeches 的顺序一致
+func (ss *SpeechService) GetGroupWeights(nameOrID string) ([]float64, error) {
+	group, err := ss.findGroup(nameOrID)
+	if err != nil {
+		return nil, err
+	}
+
+	return group.Weights, nil
+}
+
+// GetGroupEncoding 获取话术组的整体编码（按每条话术检测到的编码多数表决，平票时为 Unicode）
+func (ss *SpeechService) GetGroupEncoding(nameOrID string) (models.EncodingType, error) {
+	group, err := ss.findGroup(nameOrID)
+	if err != nil {
+		return "", err
+	}
+
+	zawgyi := 0
+	for _, encoding := range group.Encodings {
+		if encoding == models.EncodingZawgyi {
+			zawgyi++
+		}
+	}
+	if zawgyi*2 > len(group.Encodings) {
+		return models.EncodingZawgyi, nil
+	}
+	return models.EncodingUnicode, nil
+}
+
+// findGroup 按ID或名称查找话术组
+func (ss *SpeechService) findGroup(nameOrID string) (*models.SpeechGroup, error) {
 	// 先尝试按ID查找
 	if id, err := parseInt64(nameOrID); err == nil {
 		group, err := ss.GetGroup(id)
 		if err == nil {
-			return group.Speeches, nil
+			return group, nil
 		}
 	}
 
@@ -270,7 +342,7 @@ func (ss *SpeechService) GetGroupSpeeches(nameOrID string) ([]string, error) {
 		return nil, errors.New("话术组不存在: " + nameOrID)
 	}
 
-	return group.Speeches, nil
+	return group, nil
 }
 
 // parseInt64 尝试将字符串转换为int64
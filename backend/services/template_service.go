@@ -0,0 +1,311 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sayhi/backend/database"
+	"sayhi/backend/models"
+)
+
+// TemplateService 模板服务（使用数据库存储，模板按用户隔离）
+// 模板的每次修改（包括约束规则）都会记录一个不可修改的历史版本
+type TemplateService struct {
+	rules *RuleService
+}
+
+// NewTemplateService 创建模板服务
+func NewTemplateService(ruleService *RuleService) *TemplateService {
+	return &TemplateService{
+		rules: ruleService,
+	}
+}
+
+// CreateTemplate 保存模板，生成参数中的约束规则保存为模板的规则，并记录为版本1
+func (ts *TemplateService) CreateTemplate(userID int64, req *models.SavedTemplateRequest) (*models.SavedTemplate, error) {
+	if err := ts.checkName(userID, req.Name, 0); err != nil {
+		return nil, err
+	}
+
+	config, encoding, err := encodeTemplateConfig(&req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 模板、约束规则和版本1在同一事务中保存
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, errors.New("开启事务失败: " + err.Error())
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO templates (name, template, encoding, config, user_id) VALUES (?, ?, ?, ?, ?)",
+		req.Name, req.Config.Template, encoding, config, userID)
+	if err != nil {
+		return nil, errors.New("保存模板失败: " + err.Error())
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, errors.New("获取模板ID失败: " + err.Error())
+	}
+
+	if len(req.Config.Rules) > 0 {
+		if err := replaceRules(tx, id, req.Config.Rules); err != nil {
+			return nil, err
+		}
+	}
+	if err := ts.recordVersion(tx, id, userID, "创建"); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, errors.New("提交事务失败: " + err.Error())
+	}
+
+	return ts.GetTemplate(id, userID)
+}
+
+// GetTemplate 获取模板
+func (ts *TemplateService) GetTemplate(id, userID int64) (*models.SavedTemplate, error) {
+	row := database.DB.QueryRow(`SELECT id, name, template, encoding, config, created_at, updated_at
+		FROM templates WHERE id = ? AND user_id = ?`, id, userID)
+	tpl, err := scanTemplate(row)
+	if err != nil {
+		return nil, errors.New("模板不存在")
+	}
+	return tpl, nil
+}
+
+// GetAllTemplates 获取用户的所有模板
+func (ts *TemplateService) GetAllTemplates(userID int64) []models.SavedTemplate {
+	rows, err := database.DB.Query(`SELECT id, name, template, encoding, config, created_at, updated_at
+		FROM templates WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return []models.SavedTemplate{}
+	}
+	defer rows.Close()
+
+	templates := []models.SavedTemplate{}
+	for rows.Next() {
+		tpl, err := scanTemplate(rows)
+		if err != nil {
+			continue
+		}
+		templates = append(templates, *tpl)
+	}
+
+	return templates
+}
+
+// UpdateTemplate 更新模板，提供生成参数时同时以其中的约束规则替换模板的规则，并记录新版本
+func (ts *TemplateService) UpdateTemplate(id, userID int64, req *models.SavedTemplateUpdateRequest) (*models.SavedTemplate, error) {
+	if _, err := ts.GetTemplate(id, userID); err != nil {
+		return nil, err
+	}
+	if req.Name == "" && req.Config == nil {
+		return ts.GetTemplate(id, userID)
+	}
+
+	if req.Name != "" {
+		if err := ts.checkName(userID, req.Name, id); err != nil {
+			return nil, err
+		}
+	}
+
+	// 模板内容、约束规则和新版本在同一事务中保存
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, errors.New("开启事务失败: " + err.Error())
+	}
+	defer tx.Rollback()
+
+	if _, err := lockTemplate(tx, id, userID); err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		if _, err := tx.Exec("UPDATE templates SET name = ? WHERE id = ?", req.Name, id); err != nil {
+			return nil, errors.New("更新模板失败: " + err.Error())
+		}
+	}
+
+	if req.Config != nil {
+		config, encoding, err := encodeTemplateConfig(req.Config)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec("UPDATE templates SET template = ?, encoding = ?, config = ? WHERE id = ?",
+			req.Config.Template, encoding, config, id)
+		if err != nil {
+			return nil, errors.New("更新模板失败: " + err.Error())
+		}
+
+		// 生成参数整体替换，约束规则也随之替换（未提供或为空时清除全部规则）
+		if err := replaceRules(tx, id, req.Config.Rules); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ts.recordVersion(tx, id, userID, "更新"); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, errors.New("提交事务失败: " + err.Error())
+	}
+
+	return ts.GetTemplate(id, userID)
+}
+
+// SetRules 替换模板的约束规则，并记录新版本
+func (ts *TemplateService) SetRules(id, userID int64, rules []models.ConstraintRule) ([]models.ConstraintRule, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, errors.New("开启事务失败: " + err.Error())
+	}
+	defer tx.Rollback()
+
+	if _, err := lockTemplate(tx, id, userID); err != nil {
+		return nil, err
+	}
+	if err := replaceRules(tx, id, rules); err != nil {
+		return nil, err
+	}
+	if err := ts.recordVersion(tx, id, userID, "修改规则"); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, errors.New("提交事务失败: " + err.Error())
+	}
+
+	return ts.rules.GetRules(id, userID)
+}
+
+// DeleteTemplate 删除模板（由于外键约束，会自动删除模板的约束规则）
+func (ts *TemplateService) DeleteTemplate(id, userID int64) error {
+	result, err := database.DB.Exec("DELETE FROM templates WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return errors.New("删除模板失败: " + err.Error())
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return errors.New("模板不存在")
+	}
+	return nil
+}
+
+// ResolveRequest 以已保存模板（version 为0时使用最新版本）的生成参数为基础，用生成请求体中出现的字段覆盖，
+// 得到本次的生成请求，并在请求的约束规则前加上模板保存的规则；返回的请求中 TemplateVersion 为实际使用的版本号。
+// 覆盖按 JSON 字段进行：请求体中出现的字段替换保存的值，映射类字段（positions、encodings 等）按键合并
+func (ts *TemplateService) ResolveRequest(id int64, version int, userID int64, body []byte) (*models.TemplateRequest, error) {
+	var config models.TemplateRequest
+	var rules []models.ConstraintRule
+	if version > 0 {
+		v, err := ts.GetVersion(id, version, userID)
+		if err != nil {
+			return nil, err
+		}
+		config, rules = v.Config, v.Rules
+	} else {
+		tpl, err := ts.GetTemplate(id, userID)
+		if err != nil {
+			return nil, err
+		}
+		if rules, err = ts.rules.GetRules(id, userID); err != nil {
+			return nil, err
+		}
+		if version, err = latestVersion(database.DB, id); err != nil {
+			return nil, err
+		}
+		config = tpl.Config
+	}
+
+	req := config
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, errors.New("请求参数错误: " + err.Error())
+	}
+	req.TemplateID = id
+	req.TemplateVersion = version
+	req.Rules = append(rules, req.Rules...)
+	return &req, nil
+}
+
+// checkName 检查同一用户的模板名称是否重复（excludeID 为更新时排除的模板）
+func (ts *TemplateService) checkName(userID int64, name string, excludeID int64) error {
+	var count int
+	err := database.DB.QueryRow("SELECT COUNT(*) FROM templates WHERE user_id = ? AND name = ? AND id != ?", userID, name, excludeID).Scan(&count)
+	if err != nil {
+		return errors.New("查询模板失败: " + err.Error())
+	}
+	if count > 0 {
+		return errors.New("模板名称已存在")
+	}
+	return nil
+}
+
+// encodeTemplateConfig 将生成参数序列化为 JSON 保存，分页参数和模板ID不保存，
+// 约束规则单独保存在 template_rules 表中；同时返回模板编码（未指定时为 Unicode）
+func encodeTemplateConfig(req *models.TemplateRequest) (string, models.EncodingType, error) {
+	config := *req
+	config.Offset = 0
+	config.Limit = 0
+	config.TemplateID = 0
+	config.Rules = nil
+
+	data, err := json.Marshal(&config)
+	if err != nil {
+		return "", "", errors.New("序列化生成参数失败: " + err.Error())
+	}
+
+	encoding := config.Encoding
+	if encoding == "" {
+		encoding = models.EncodingUnicode
+	}
+	return string(data), encoding, nil
+}
+
+// rowScanner sql.Row 和 sql.Rows 共有的 Scan 方法
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryer database.DB 和事务共有的查询方法
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// lockTemplate 在事务中读取并锁定模板行（SELECT ... FOR UPDATE），模板必须属于指定用户；
+// 同一模板的修改在锁上排队，版本号在持有锁之后计算，不会出现两个修改得到相同的版本号
+func lockTemplate(tx *sql.Tx, id, userID int64) (*models.SavedTemplate, error) {
+	row := tx.QueryRow(`SELECT id, name, template, encoding, config, created_at, updated_at
+		FROM templates WHERE id = ? AND user_id = ? FOR UPDATE`, id, userID)
+	tpl, err := scanTemplate(row)
+	if err != nil {
+		return nil, errors.New("模板不存在")
+	}
+	return tpl, nil
+}
+
+// scanTemplate 读取一行模板记录，config 为空（旧数据）时以模板内容和编码构造生成参数
+func scanTemplate(row rowScanner) (*models.SavedTemplate, error) {
+	var tpl models.SavedTemplate
+	var config sql.NullString
+	if err := row.Scan(&tpl.ID, &tpl.Name, &tpl.Template, &tpl.Encoding, &config, &tpl.CreatedAt, &tpl.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if config.Valid && config.String != "" {
+		if err := json.Unmarshal([]byte(config.String), &tpl.Config); err != nil {
+			return nil, err
+		}
+	} else {
+		tpl.Config = models.TemplateRequest{
+			Template:     tpl.Template,
+			Encoding:     tpl.Encoding,
+			GenerateMode: models.GenerateSequential,
+		}
+	}
+	return &tpl, nil
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sayhi/backend/database"
+	"sayhi/backend/models"
+	"sort"
+)
+
+// GetVersions 获取模板的全部历史版本（按版本号从新到旧排列）
+func (ts *TemplateService) GetVersions(templateID, userID int64) ([]models.TemplateVersion, error) {
+	if _, err := ts.GetTemplate(templateID, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(`SELECT v.id, v.template_id, v.version, v.name, v.template, v.encoding, v.config, v.rules,
+		v.author_id, COALESCE(u.username, ''), v.comment, v.created_at
+		FROM template_versions v LEFT JOIN users u ON u.id = v.author_id
+		WHERE v.template_id = ? ORDER BY v.version DESC`, templateID)
+	if err != nil {
+		return nil, errors.New("查询模板版本失败: " + err.Error())
+	}
+	defer rows.Close()
+
+	versions := []models.TemplateVersion{}
+	for rows.Next() {
+		v, err := scanTemplateVersion(rows)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, *v)
+	}
+
+	return versions, nil
+}
+
+// GetVersion 获取模板的指定版本
+func (ts *TemplateService) GetVersion(templateID int64, version int, userID int64) (*models.TemplateVersion, error) {
+	if _, err := ts.GetTemplate(templateID, userID); err != nil {
+		return nil, err
+	}
+
+	row := database.DB.QueryRow(`SELECT v.id, v.template_id, v.version, v.name, v.template, v.encoding, v.config, v.rules,
+		v.author_id, COALESCE(u.username, ''), v.comment, v.created_at
+		FROM template_versions v LEFT JOIN users u ON u.id = v.author_id
+		WHERE v.template_id = ? AND v.version = ?`, templateID, version)
+	v, err := scanTemplateVersion(row)
+	if err != nil {
+		return nil, fmt.Errorf("模板版本不存在: %d", version)
+	}
+	return v, nil
+}
+
+// DiffVersions 比较模板的两个版本，to 为0时与最新版本比较
+func (ts *TemplateService) DiffVersions(templateID int64, from, to int, userID int64) (*models.TemplateVersionDiff, error) {
+	if to == 0 {
+		latest, err := latestVersion(database.DB, templateID)
+		if err != nil {
+			return nil, err
+		}
+		to = latest
+	}
+
+	old, err := ts.GetVersion(templateID, from, userID)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := ts.GetVersion(templateID, to, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.TemplateVersionDiff{
+		TemplateID: templateID,
+		From:       from,
+		To:         to,
+		Changes:    []models.TemplateFieldChange{},
+	}
+	if old.Name != cur.Name {
+		diff.Changes = append(diff.Changes, models.TemplateFieldChange{Field: "name", From: old.Name, To: cur.Name})
+	}
+	if old.Template != cur.Template {
+		diff.Changes = append(diff.Changes, models.TemplateFieldChange{Field: "template", From: old.Template, To: cur.Template})
+	}
+	if old.Encoding != cur.Encoding {
+		diff.Changes = append(diff.Changes, models.TemplateFieldChange{Field: "encoding", From: old.Encoding, To: cur.Encoding})
+	}
+
+	configChanges, err := diffConfig(&old.Config, &cur.Config)
+	if err != nil {
+		return nil, err
+	}
+	diff.Changes = append(diff.Changes, configChanges...)
+	diff.RulesAdded, diff.RulesRemoved = diffRules(old.Rules, cur.Rules)
+
+	return diff, nil
+}
+
+// RestoreVersion 将模板恢复为指定版本的内容、生成参数和约束规则（名称保持不变），并记录为新版本
+// 早期版本未记录约束规则时保留当前的规则
+func (ts *TemplateService) RestoreVersion(templateID int64, version int, userID int64) (*models.SavedTemplate, error) {
+	v, err := ts.GetVersion(templateID, version, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, encoding, err := encodeTemplateConfig(&v.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, errors.New("开启事务失败: " + err.Error())
+	}
+	defer tx.Rollback()
+
+	if _, err := lockTemplate(tx, templateID, userID); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("UPDATE templates SET template = ?, encoding = ?, config = ? WHERE id = ?",
+		v.Template, encoding, config, templateID)
+	if err != nil {
+		return nil, errors.New("恢复模板失败: " + err.Error())
+	}
+
+	if v.Rules != nil {
+		if err := replaceRules(tx, templateID, v.Rules); err != nil {
+			return nil, err
+		}
+	}
+	if err := ts.recordVersion(tx, templateID, userID, fmt.Sprintf("从版本 %d 恢复", version)); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, errors.New("提交事务失败: " + err.Error())
+	}
+
+	return ts.GetTemplate(templateID, userID)
+}
+
+// recordVersion 在修改模板的事务中将模板当前的内容、生成参数和约束规则记录为一个新版本，
+// 与修改一起提交；模板行在计算版本号之前锁定，并发修改同一模板时版本号依次递增
+func (ts *TemplateService) recordVersion(tx *sql.Tx, templateID, authorID int64, comment string) error {
+	tpl, err := lockTemplate(tx, templateID, authorID)
+	if err != nil {
+		return err
+	}
+	rules, err := loadRules(tx, templateID)
+	if err != nil {
+		return err
+	}
+
+	config, _, err := encodeTemplateConfig(&tpl.Config)
+	if err != nil {
+		return err
+	}
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return errors.New("序列化约束规则失败: " + err.Error())
+	}
+
+	latest, err := latestVersion(tx, templateID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO template_versions (template_id, version, name, template, encoding, config, rules, author_id, comment)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		templateID, latest+1, tpl.Name, tpl.Template, tpl.Encoding, config, string(rulesJSON), authorID, comment)
+	if err != nil {
+		return errors.New("记录模板版本失败: " + err.Error())
+	}
+	return nil
+}
+
+// latestVersion 返回模板的最新版本号，没有版本记录时为0
+func latestVersion(q queryer, templateID int64) (int, error) {
+	var version int
+	err := q.QueryRow("SELECT COALESCE(MAX(version), 0) FROM template_versions WHERE template_id = ?", templateID).Scan(&version)
+	if err != nil {
+		return 0, errors.New("查询模板版本失败: " + err.Error())
+	}
+	return version, nil
+}
+
+// scanTemplateVersion 读取一行版本记录，rules 为空（未记录规则的早期版本）时 Rules 为 nil
+func scanTemplateVersion(row rowScanner) (*models.TemplateVersion, error) {
+	var v models.TemplateVersion
+	var config, rules sql.NullString
+	err := row.Scan(&v.ID, &v.TemplateID, &v.Version, &v.Name, &v.Template, &v.Encoding, &config, &rules,
+		&v.AuthorID, &v.Author, &v.Comment, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Valid && config.String != "" {
+		if err := json.Unmarshal([]byte(config.String), &v.Config); err != nil {
+			return nil, err
+		}
+	} else {
+		v.Config = models.TemplateRequest{
+			Template:     v.Template,
+			Encoding:     v.Encoding,
+			GenerateMode: models.GenerateSequential,
+		}
+	}
+	if rules.Valid && rules.String != "" {
+		if err := json.Unmarshal([]byte(rules.String), &v.Rules); err != nil {
+			return nil, err
+		}
+	}
+	return &v, nil
+}
+
+// diffConfig 按 JSON 字段比较两个版本的生成参数，字段名以 config. 开头，按字段名排序
+func diffConfig(old, cur *models.TemplateRequest) ([]models.TemplateFieldChange, error) {
+	oldFields, err := configFields(old)
+	if err != nil {
+		return nil, err
+	}
+	curFields, err := configFields(cur)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(oldFields)+len(curFields))
+	for key := range oldFields {
+		keys = append(keys, key)
+	}
+	for key := range curFields {
+		if _, exists := oldFields[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []models.TemplateFieldChange
+	for _, key := range keys {
+		from, to := oldFields[key], curFields[key]
+		if string(from) == string(to) {
+			continue
+		}
+		change := models.TemplateFieldChange{Field: "config." + key}
+		if from != nil {
+			change.From = from
+		}
+		if to != nil {
+			change.To = to
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// configFields 将生成参数序列化为字段名到 JSON 值的映射（映射类字段的键已排序，可以直接比较）
+func configFields(config *models.TemplateRequest) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.New("序列化生成参数失败: " + err.Error())
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, errors.New("解析生成参数失败: " + err.Error())
+	}
+	return fields, nil
+}
+
+// diffRules 比较两组约束规则，返回新增和删除的规则（按位置、值、类型、目标位置和目标值判断是否相同）
+func diffRules(old, cur []models.ConstraintRule) (added, removed []models.ConstraintRule) {
+	key := func(rule models.ConstraintRule) string {
+		data, _ := json.Marshal([]string{rule.Position, rule.Value, string(rule.Type), rule.TargetPosition, rule.TargetValue})
+		return string(data)
+	}
+
+	oldKeys := make(map[string]bool, len(old))
+	for _, rule := range old {
+		oldKeys[key(rule)] = true
+	}
+	curKeys := make(map[string]bool, len(cur))
+	for _, rule := range cur {
+		curKeys[key(rule)] = true
+	}
+
+	added = []models.ConstraintRule{}
+	removed = []models.ConstraintRule{}
+	for _, rule := range cur {
+		if !oldKeys[key(rule)] {
+			added = append(added, rule)
+		}
+	}
+	for _, rule := range old {
+		if !curKeys[key(rule)] {
+			removed = append(removed, rule)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// DefaultWeight 未指定权重时的默认权重
+const DefaultWeight = 1.0
+
+// maxWeightedMisses 连续抽到重复组合的次数上限，超过后改为按置换顺序补齐剩余组合
+const maxWeightedMisses = 64
+
+// weightAt 返回第 i 个值的权重，未提供时为默认权重
+func weightAt(weights []float64, i int) float64 {
+	if i < len(weights) {
+		return weights[i]
+	}
+	return DefaultWeight
+}
+
+// ValidateWeights 校验权重列表
+// 未提供权重时视为全部为默认权重；提供时数量必须与值的数量一致，且均为非负有限数，不能全部为0
+func ValidateWeights(weights []float64, count int) error {
+	if len(weights) == 0 {
+		return nil
+	}
+	if len(weights) != count {
+		return fmt.Errorf("权重数量（%d）与值的数量（%d）不一致", len(weights), count)
+	}
+
+	sum := 0.0
+	for _, w := range weights {
+		if math.IsNaN(w) || math.IsInf(w, 0) || w < 0 {
+			return fmt.Errorf("权重必须是非负数")
+		}
+		sum += w
+	}
+	if sum == 0 {
+		return fmt.Errorf("权重不能全部为0")
+	}
+	return nil
+}
+
+// isUniformWeights 判断权重是否全部相同（相当于未设置权重）
+func isUniformWeights(weights []float64) bool {
+	for _, w := range weights {
+		if w != weights[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// WeightedSampler 按权重不重复地抽取组合下标
+// 每个位置按各自的权重独立抽取一个值，组合被抽中的概率与各值权重的乘积成正比；
+// 抽到已产出的组合时重新抽取，连续重复过多（剩余组合集中在低权重区域）时，
+// 改为按 Feistel 置换顺序补齐尚未产出的组合，因此最终仍能不重复地产出全部可能出现的组合。
+// 包含权重为0的值的组合出现概率为0，任何情况下都不会产出。
+// 已产出的组合下标保存在内存中，内存占用与产出数量成正比
+type WeightedSampler struct {
+	space      *CombinationSpace
+	cumulative [][]float64 // 每个位置的累计权重，nil 表示等权
+	rng        *rand.Rand
+	seen       map[int64]bool
+	indices    []int
+	possible   int64 // 不包含权重为0的值的组合数量
+
+	fallback     IndexOrder
+	fallbackNext int64
+	exhausted    bool // 是否已改为按置换顺序补齐
+}
+
+// NewWeightedSampler 创建加权抽样器，weights 与位置一一对应，nil 表示该位置等权
+func NewWeightedSampler(space *CombinationSpace, weights [][]float64, seed int64) *WeightedSampler {
+	cumulative := make([][]float64, space.Size())
+	possible := space.Total()
+	for i := range cumulative {
+		if i >= len(weights) || weights[i] == nil {
+			continue
+		}
+		sum := 0.0
+		nonzero := int64(0)
+		cumulative[i] = make([]float64, len(weights[i]))
+		for j, w := range weights[i] {
+			sum += w
+			cumulative[i][j] = sum
+			if w > 0 {
+				nonzero++
+			}
+		}
+		if space.radix[i] > 0 {
+			possible = possible / space.radix[i] * nonzero
+		}
+	}
+
+	return &WeightedSampler{
+		space:      space,
+		cumulative: cumulative,
+		rng:        rand.New(rand.NewSource(seed)),
+		seen:       make(map[int64]bool),
+		indices:    make([]int, space.Size()),
+		possible:   possible,
+		fallback:   NewFeistelOrder(space.Total(), seed),
+	}
+}
+
+// Next 返回下一个组合下标，全部可能出现的组合都已产出时 ok 为 false
+func (s *WeightedSampler) Next() (index int64, ok bool) {
+	if int64(len(s.seen)) >= s.possible {
+		return 0, false
+	}
+
+	if !s.exhausted {
+		for misses := 0; misses < maxWeightedMisses; misses++ {
+			index = s.draw()
+			if !s.seen[index] {
+				s.seen[index] = true
+				return index, true
+			}
+		}
+		s.exhausted = true
+	}
+
+	for s.fallbackNext < s.space.Total() {
+		index = s.fallback(s.fallbackNext)
+		s.fallbackNext++
+		if !s.seen[index] && s.possibleAt(index) {
+			s.seen[index] = true
+			return index, true
+		}
+	}
+	return 0, false
+}
+
+// draw 按权重为每个位置抽取一个值，返回对应的组合下标
+func (s *WeightedSampler) draw() int64 {
+	for i, cumulative := range s.cumulative {
+		if cumulative == nil {
+			s.indices[i] = int(s.rng.Int63n(s.space.radix[i]))
+			continue
+		}
+		target := s.rng.Float64() * cumulative[len(cumulative)-1]
+		j := sort.SearchFloat64s(cumulative, target)
+		// 跳过权重为0的值（累计权重与前一个相同）
+		for j < len(cumulative)-1 && cumulative[j] <= target {
+			j++
+		}
+		s.indices[i] = j
+	}
+	return s.space.IndexOf(s.indices)
+}
+
+// possibleAt 判断组合是否可能被抽中（不包含权重为0的值）
+func (s *WeightedSampler) possibleAt(index int64) bool {
+	s.indices = s.space.IndicesAt(index, s.indices)
+	for i, cumulative := range s.cumulative {
+		j := s.indices[i]
+		if cumulative == nil {
+			continue
+		}
+		if j == 0 && cumulative[0] == 0 || j > 0 && cumulative[j] == cumulative[j-1] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"sayhi/backend/models"
+	"strings"
+	"testing"
+)
+
+func TestWeightedSamplerSkipsZeroWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  [][]string
+		weights [][]float64
+		want    int // 可能出现的组合数量
+	}{
+		{name: "单个位置", values: [][]string{{"A1", "A2", "A3"}}, weights: [][]float64{{100, 1, 0}}, want: 2},
+		{name: "第一个值权重为0", values: [][]string{{"A1", "A2", "A3"}}, weights: [][]float64{{0, 1, 5}}, want: 2},
+		{name: "多个位置", values: [][]string{{"A1", "A2"}, {"B1", "B2", "B3"}}, weights: [][]float64{{0, 1}, {1, 0, 1}}, want: 2},
+		{name: "部分位置等权", values: [][]string{{"A1", "A2", "A3"}, {"B1", "B2"}}, weights: [][]float64{{1000, 0, 1}, nil}, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			space, err := NewCombinationSpace(tt.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for seed := int64(0); seed < 20; seed++ {
+				sampler := NewWeightedSampler(space, tt.weights, seed)
+				seen := make(map[int64]bool)
+				for {
+					index, ok := sampler.Next()
+					if !ok {
+						break
+					}
+					if seen[index] {
+						t.Fatalf("种子 %d 重复产出组合 %d", seed, index)
+					}
+					seen[index] = true
+					for i, j := range space.IndicesAt(index, nil) {
+						if tt.weights[i] != nil && tt.weights[i][j] == 0 {
+							t.Fatalf("种子 %d 产出了包含权重为0的值 %s 的组合", seed, tt.values[i][j])
+						}
+					}
+				}
+				if len(seen) != tt.want {
+					t.Errorf("种子 %d 产出 %d 个组合，期望 %d 个", seed, len(seen), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateWeightedMatchesDistribution(t *testing.T) {
+	seed := int64(1)
+	req := &models.TemplateRequest{
+		Template:     "Hi (a), visit (b) today!",
+		GenerateMode: models.GenerateSample,
+		Positions:    models.PositionConfig{"a": {"A1", "A2", "A3"}, "b": {"B1"}},
+		Weights:      map[string][]float64{"a": {100, 1, 0}},
+		SampleSize:   3,
+		Seed:         &seed,
+	}
+	resp, err := NewTemplateGenerator(NewSpeechService()).Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zero := make(map[string]bool)
+	for _, p := range resp.Distribution["a"] {
+		if p.Probability == 0 {
+			zero[p.Value] = true
+		}
+	}
+	if !zero["A3"] {
+		t.Fatalf("A3 的期望出现概率应为0: %+v", resp.Distribution["a"])
+	}
+	if len(resp.Results) != 2 {
+		t.Errorf("得到 %d 条结果，期望 2 条", len(resp.Results))
+	}
+	for _, r := range resp.Results {
+		if strings.Contains(r.Content, "A3") {
+			t.Errorf("结果 %q 包含期望出现概率为0的值", r.Content)
+		}
+	}
+}
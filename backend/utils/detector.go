@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"math"
+	"sayhi/backend/models"
+)
+
+// Zawgyi/Unicode 检测器
+// 使用一阶马尔可夫模型：分别估计 Unicode 与 Zawgyi 文本中相邻字符的转移概率，
+// 对待检测文本累加两种模型下的对数似然比，再通过 sigmoid 得到文本为 Zawgyi 的概率。
+// 两种模型分别用实际的 Unicode 和 Zawgyi 写法训练（Zawgyi 样本保留 ႏ、႐、ၾ、၀ 等常见的替代字形），
+// 不依赖转换规则生成训练语料
+
+// ZawgyiThreshold 判定为 Zawgyi 的概率阈值
+// 误判为 Zawgyi 会使字符数按字节计算、并在转换输出时改写内容，代价远高于漏判，因此要求明显更像 Zawgyi
+const ZawgyiThreshold = 0.95
+
+// 字符状态：辅音、数字、非缅甸文字符各合并为一个状态，其余缅甸文码位各自一个状态
+const (
+	stateBoundary  = 0 // 文本开始/结束及非缅甸文字符
+	stateConsonant = 1 // 辅音 U+1000-U+1021
+	stateDigit     = 2 // 数字 U+1040-U+1049
+	stateBase      = 3 // 其余缅甸文码位从此开始编号
+	stateCount     = stateBase + 0xa0
+)
+
+// detectorCorpus 训练语料：同一内容的 Unicode 和 Zawgyi 写法，覆盖短信中常见的辅音、中置符、元音和 kinzi 组合
+var detectorCorpus = []struct {
+	unicode string
+	zawgyi  string
+}{
+	{"မင်္ဂလာပါ", "မဂၤလာပါ"},
+	{"ကျေးဇူးတင်ပါတယ်", "ေက်းဇူးတင္ပါတယ္"},
+	{"နေကောင်းလား", "ေနေကာင္းလား"},
+	{"မြန်မာနိုင်ငံ", "ျမန္မာႏိုင္ငံ"},
+	{"ဘယ်လိုလဲ", "ဘယ္လိုလဲ"},
+	{"ငွေလွှဲပို့ခြင်း", "ေငြလႊဲပို႔ျခင္း"},
+	{"အခမဲ့", "အခမဲ့"},
+	{"ဆုကြေးငွေ", "ဆုေၾကးေငြ"},
+	{"ယနေ့", "ယေန႔"},
+	{"ဖုန်းနံပါတ်", "ဖုန္းနံပါတ္"},
+	{"အကောင့်ဖွင့်ပါ", "အေကာင့္ဖြင့္ပါ"},
+	{"ဝန်ဆောင်မှု", "၀န္ေဆာင္မႈ"},
+	{"ချစ်တယ်", "ခ်စ္တယ္"},
+	{"သတင်းကောင်း", "သတင္းေကာင္း"},
+	{"ပရိုမိုးရှင်း", "ပ႐ိုမိုးရွင္း"},
+	{"အထူးလျှော့ဈေး", "အထူးေလွ်ာ့ေစ်း"},
+	{"ယခုပဲ ဝင်ရောက်ကစားလိုက်ပါ", "ယခုပဲ ၀င္ေရာက္ကစားလိုက္ပါ"},
+	{"ကျွန်ုပ်တို့", "ကၽြန္ုပ္တို႔"},
+	{"လူကြီးမင်း", "လူႀကီးမင္း"},
+	{"ရက်အတွင်း", "ရက္အတြင္း"},
+	{"ငွေထုတ်ယူနိုင်ပါသည်", "ေငြထုတ္ယူႏိုင္ပါသည္"},
+	{"အချိန်မရွေး", "အခ်ိန္မေရြး"},
+	{"မှတ်ပုံတင်ပါ", "မွတ္ပုံတင္ပါ"},
+	{"လက်ဆောင်", "လက္ေဆာင္"},
+	{"သင်္ချိုင်း", "သခႋ်ဳင္း"},
+	{"ပြောပါ", "ေျပာပါ"},
+	{"ကြွေးမြီ", "ေႂကြးၿမီ"},
+	{"ရွှေ", "ေ႐ႊ"},
+	{"နှုတ်ဆက်ပါသည်", "ႏႈတ္ဆက္ပါသည္"},
+	{"ကျောင်းသား", "ေက်ာင္းသား"},
+	{"စိတ်ဝင်စားဖွယ်", "စိတ္၀င္စားဖြယ္"},
+	{"ဘဏ်အကောင့်", "ဘဏ္အေကာင့္"},
+	{"အွန်လိုင်း", "အြန္လိုင္း"},
+	{"ညွှန်ကြားချက်", "ၫႊန္ၾကားခ်က္"},
+	{"ပိုမိုကောင်းမွန်", "ပိုမိုေကာင္းမြန္"},
+	{"ဖြည့်သွင်းပါ", "ျဖည့္သြင္းပါ"},
+	{"အမြန်ဆုံး", "အျမန္ဆုံး"},
+	{"ဆက်သွယ်ရန်", "ဆက္သြယ္ရန္"},
+	{"တောင်းဆိုမှု", "ေတာင္းဆိုမႈ"},
+	{"ကံကောင်းပါစေ", "ကံေကာင္းပါေစ"},
+	{"အားလုံးအတွက်", "အားလုံးအတြက္"},
+	{"ရုံးဖွင့်ရက်", "႐ုံးဖြင့္ရက္"},
+	{"နှင့်", "ႏွင့္"},
+	{"ပြည်သူများ", "ျပည္သူမ်ား"},
+	{"လာရောက်ပါ", "လာေရာက္ပါ"},
+}
+
+// markovModel 一阶马尔可夫模型（对数转移概率）
+type markovModel [stateCount][stateCount]float64
+
+var (
+	unicodeModel markovModel
+	zawgyiModel  markovModel
+)
+
+func init() {
+	unicodeCorpus := make([]string, len(detectorCorpus))
+	zawgyiCorpus := make([]string, len(detectorCorpus))
+	for i, sample := range detectorCorpus {
+		unicodeCorpus[i] = sample.unicode
+		zawgyiCorpus[i] = sample.zawgyi
+	}
+	unicodeModel = trainMarkovModel(unicodeCorpus)
+	zawgyiModel = trainMarkovModel(zawgyiCorpus)
+}
+
+// detectorState 将字符映射为模型状态
+func detectorState(r rune) int {
+	switch {
+	case r >= 0x1000 && r <= 0x1021:
+		return stateConsonant
+	case r >= 0x1040 && r <= 0x1049:
+		return stateDigit
+	case r >= 0x1000 && r <= 0x109f:
+		return stateBase + int(r-0x1000)
+	default:
+		return stateBoundary
+	}
+}
+
+// trainMarkovModel 统计转移次数并做加一平滑，得到对数转移概率
+func trainMarkovModel(corpus []string) markovModel {
+	var counts [stateCount][stateCount]float64
+	for _, text := range corpus {
+		prev := stateBoundary
+		for _, r := range text {
+			state := detectorState(r)
+			counts[prev][state]++
+			prev = state
+		}
+		counts[prev][stateBoundary]++
+	}
+
+	var model markovModel
+	for from := 0; from < stateCount; from++ {
+		total := float64(stateCount)
+		for to := 0; to < stateCount; to++ {
+			total += counts[from][to]
+		}
+		for to := 0; to < stateCount; to++ {
+			model[from][to] = math.Log((counts[from][to] + 1) / total)
+		}
+	}
+	return model
+}
+
+// ZawgyiProbability 返回文本为 Zawgyi 编码的概率
+// 不包含缅甸文字符时返回 -1；按 Zawgyi 转换后内容不变的文本（如 အားလုံး）在两种编码下含义相同，按 Unicode 处理，返回0；
+// 有词首的 ေ 或 ျ 时返回1
+func ZawgyiProbability(text string) float64 {
+	if !containsMyanmar(text) {
+		return -1
+	}
+	if ZawgyiToUnicode(text) == text {
+		return 0
+	}
+	if hasLeadingPrefix(text) {
+		return 1
+	}
+
+	score := 0.0
+	prev := stateBoundary
+	for _, r := range text {
+		state := detectorState(r)
+		// 只统计与缅甸文相关的转移，避免非缅甸文内容影响结果
+		if prev != stateBoundary || state != stateBoundary {
+			score += zawgyiModel[prev][state] - unicodeModel[prev][state]
+		}
+		prev = state
+	}
+	if prev != stateBoundary {
+		score += zawgyiModel[prev][stateBoundary] - unicodeModel[prev][stateBoundary]
+	}
+
+	return 1 / (1 + math.Exp(-score))
+}
+
+// hasLeadingPrefix 判断是否有词首的前置元音 ေ 或中置符 ျ（Zawgyi 的 ra 中置符）
+// Zawgyi 把它们写在辅音之前，Unicode 中它们必须跟在辅音之后，出现在词首时只可能是 Zawgyi
+func hasLeadingPrefix(text string) bool {
+	prev := stateBoundary
+	for _, r := range text {
+		if (r == 'ေ' || r == 'ျ') && prev == stateBoundary {
+			return true
+		}
+		prev = detectorState(r)
+	}
+	return false
+}
+
+// DetectMyanmarEncoding 检测文本的缅甸文编码
+// 返回 Zawgyi 或 Unicode，以及判定为 Zawgyi 的概率；概率超过 ZawgyiThreshold 时才判定为 Zawgyi，
+// 不包含缅甸文字符时按 Unicode 处理，概率为 -1
+func DetectMyanmarEncoding(text string) (models.EncodingType, float64) {
+	probability := ZawgyiProbability(text)
+	if probability > ZawgyiThreshold {
+		return models.EncodingZawgyi, probability
+	}
+	return models.EncodingUnicode, probability
+}
+
+// DetectValuesEncoding 检测一组值的整体编码（按包含缅甸文的值多数表决，平票时为 Unicode）
+func DetectValuesEncoding(values []string) models.EncodingType {
+	zawgyi, unicode := 0, 0
+	for _, value := range values {
+		encoding, probability := DetectMyanmarEncoding(value)
+		if probability < 0 {
+			continue
+		}
+		if encoding == models.EncodingZawgyi {
+			zawgyi++
+		} else {
+			unicode++
+		}
+	}
+	if zawgyi > unicode {
+		return models.EncodingZawgyi
+	}
+	return models.EncodingUnicode
+}
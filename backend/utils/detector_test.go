@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"sayhi/backend/models"
+	"testing"
+)
+
+func TestDetectorCorpusIsConsistent(t *testing.T) {
+	for _, sample := range detectorCorpus {
+		if got := ZawgyiToUnicode(sample.zawgyi); got != sample.unicode {
+			t.Errorf("训练语料 %q 的 Zawgyi 写法 %q 转换为 %q", sample.unicode, sample.zawgyi, got)
+		}
+	}
+}
+
+func TestDetectMyanmarEncoding(t *testing.T) {
+	tests := []struct {
+		text string
+		want models.EncodingType
+	}{
+		// 两种编码下写法相同的词按 Unicode 处理
+		{"အားလုံး", models.EncodingUnicode},
+		{"ကို", models.EncodingUnicode},
+		{"ဘာလဲ", models.EncodingUnicode},
+		{"ရုံး", models.EncodingUnicode},
+		{"မိုး", models.EncodingUnicode},
+
+		// Unicode
+		{"ကျေးဇူး", models.EncodingUnicode},
+		{"ငွေ", models.EncodingUnicode},
+		{"မြို့", models.EncodingUnicode},
+		{"ကျွန်တော်", models.EncodingUnicode},
+		{"လျှော့စျေး", models.EncodingUnicode},
+		{"ကြော်ငြာ", models.EncodingUnicode},
+		{"ဝယ်ယူပါ", models.EncodingUnicode},
+		{"မင်္ဂလာပါ ရှင့်", models.EncodingUnicode},
+
+		// Zawgyi
+		{"ေက်းဇူး", models.EncodingZawgyi},
+		{"ေငြ", models.EncodingZawgyi},
+		{"ျမို႕", models.EncodingZawgyi},
+		{"ကၽြန္ေတာ္", models.EncodingZawgyi},
+		{"ေလွ်ာ့ေစ်း", models.EncodingZawgyi},
+		{"ေၾကာ္ျငာ", models.EncodingZawgyi},
+		{"၀ယ္ယူပါ", models.EncodingZawgyi},
+		{"မဂၤလာပါ ရွင့္", models.EncodingZawgyi},
+		{"သြားမယ္", models.EncodingZawgyi},
+	}
+	for _, tt := range tests {
+		if got, probability := DetectMyanmarEncoding(tt.text); got != tt.want {
+			t.Errorf("DetectMyanmarEncoding(%q) = %s（概率 %.3f），期望 %s", tt.text, got, probability, tt.want)
+		}
+	}
+}
+
+func TestZawgyiProbabilityWithoutMyanmar(t *testing.T) {
+	for _, text := range []string{"", "Hello", "你好 123"} {
+		if got := ZawgyiProbability(text); got != -1 {
+			t.Errorf("ZawgyiProbability(%q) = %v，期望 -1", text, got)
+		}
+	}
+}
+
+func TestDetectValuesEncoding(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   models.EncodingType
+	}{
+		{[]string{"Hello", "123"}, models.EncodingUnicode},
+		{[]string{"ငွေ", "ကျေးဇူး", "Hi"}, models.EncodingUnicode},
+		{[]string{"ေငြ", "ေက်းဇူး", "Hi"}, models.EncodingZawgyi},
+		// 平票时为 Unicode
+		{[]string{"ေငြ", "ငွေ"}, models.EncodingUnicode},
+	}
+	for _, tt := range tests {
+		if got := DetectValuesEncoding(tt.values); got != tt.want {
+			t.Errorf("DetectValuesEncoding(%q) = %s，期望 %s", tt.values, got, tt.want)
+		}
+	}
+}
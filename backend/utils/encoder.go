@@ -10,10 +10,15 @@ func CountChars(text string, encoding models.EncodingType) int {
 	switch encoding {
 	case models.EncodingASCII:
 		return countASCII(text)
+	case models.EncodingGSM7:
+		septets, _ := CountGSM7(text)
+		return septets
 	case models.EncodingZawgyi:
 		return countZawgyi(text)
 	case models.EncodingUnicode:
 		return utf8.RuneCountInString(text)
+	case models.EncodingUCS2:
+		return CountUTF16Units(text)
 	case models.EncodingOther:
 		return len([]byte(text))
 	default:
@@ -21,6 +26,28 @@ func CountChars(text string, encoding models.EncodingType) int {
 	}
 }
 
+// RuneChars 根据编码类型计算单个字符占用的字符数，逐字符累加的结果与 CountChars 相同
+func RuneChars(r rune, encoding models.EncodingType) int {
+	switch encoding {
+	case models.EncodingASCII:
+		if r < 128 {
+			return 1
+		}
+		return 0
+	case models.EncodingGSM7:
+		if n := GSM7Septets(r); n > 0 {
+			return n
+		}
+		return 1
+	case models.EncodingZawgyi, models.EncodingOther:
+		return utf8.RuneLen(r)
+	case models.EncodingUCS2:
+		return UTF16Units(r)
+	default:
+		return 1
+	}
+}
+
 // countASCII 计算ASCII字符数（只计算ASCII字符）
 func countASCII(text string) int {
 	count := 0
@@ -32,6 +59,31 @@ func countASCII(text string) int {
 	return count
 }
 
+// CountUTF16Units 计算 UTF-16 编码单元数（UCS-2 短信按此计费）
+// 基本多文种平面以外的字符（如 emoji）需要代理对表示，占2个单元
+func CountUTF16Units(text string) int {
+	count := 0
+	for _, r := range text {
+		count += UTF16Units(r)
+	}
+	return count
+}
+
+// UTF16Units 返回单个字符的 UTF-16 编码单元数
+func UTF16Units(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
+
+// NonGSM7Chars 返回文本中无法用 GSM-7 表示的字符（按出现顺序去重），
+// 即导致整条短信必须改用 UCS-2 发送的字符
+func NonGSM7Chars(text string) []string {
+	_, chars := CountGSM7(text)
+	return chars
+}
+
 // countZawgyi 计算Zawgyi字符数（按字节计算，Zawgyi通常需要更多字节）
 func countZawgyi(text string) int {
 	// Zawgyi编码通常每个字符占用2-3字节
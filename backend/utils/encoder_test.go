@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"sayhi/backend/models"
+	"testing"
+)
+
+func TestCountCharsMatchesRuneChars(t *testing.T) {
+	encodings := []models.EncodingType{
+		models.EncodingASCII, models.EncodingGSM7, models.EncodingUnicode,
+		models.EncodingUCS2, models.EncodingZawgyi, models.EncodingOther,
+	}
+	texts := []string{"", "Hello", "5€ {x}", "မင်္ဂလာပါ", "Hi 😀!", "ç中"}
+	for _, encoding := range encodings {
+		for _, text := range texts {
+			sum := 0
+			for _, r := range text {
+				sum += RuneChars(r, encoding)
+			}
+			if got := CountChars(text, encoding); got != sum {
+				t.Errorf("CountChars(%q, %s) = %d，逐字符累加为 %d", text, encoding, got, sum)
+			}
+		}
+	}
+}
+
+func TestCountUTF16Units(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"中文", 2},
+		{"😀", 2},
+		{"a😀b", 4},
+		{"မင်္ဂလာပါ", 9},
+	}
+	for _, tt := range tests {
+		if got := CountUTF16Units(tt.text); got != tt.want {
+			t.Errorf("CountUTF16Units(%q) = %d，期望 %d", tt.text, got, tt.want)
+		}
+	}
+}
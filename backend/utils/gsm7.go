@@ -0,0 +1,83 @@
+package utils
+
+// GSM 03.38 (3GPP TS 23.038) 7位默认字母表
+// 基本字符表中的字符占1个septet，扩展字符表中的字符需要 ESC(0x1B) 前缀，占2个septet
+
+// gsm7BasicChars 基本字符表，按编码值 0x00-0x7F 排列（0x1B 为转义符，不可直接使用）
+const gsm7BasicChars = "@£$¥èéùìòÇ\nØø\rÅå" +
+	"Δ_ΦΓΛΩΠΨΣΘΞ\x1bÆæßÉ" +
+	" !\"#¤%&'()*+,-./" +
+	"0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNO" +
+	"PQRSTUVWXYZÄÖÑÜ§" +
+	"¿abcdefghijklmno" +
+	"pqrstuvwxyzäöñüà"
+
+// gsm7ExtensionChars 扩展字符表中的字符
+const gsm7ExtensionChars = "\f^{}\\[~]|€"
+
+var (
+	gsm7Basic     = make(map[rune]bool)
+	gsm7Extension = make(map[rune]bool)
+)
+
+func init() {
+	for _, r := range gsm7BasicChars {
+		if r != 0x1b {
+			gsm7Basic[r] = true
+		}
+	}
+	for _, r := range gsm7ExtensionChars {
+		gsm7Extension[r] = true
+	}
+}
+
+// GSM7Septets 返回单个字符在 GSM-7 中占用的 septet 数
+// 基本字符表返回1，扩展字符表返回2，无法表示的字符返回0
+func GSM7Septets(r rune) int {
+	if gsm7Basic[r] {
+		return 1
+	}
+	if gsm7Extension[r] {
+		return 2
+	}
+	return 0
+}
+
+// IsGSM7Char 判断字符能否用 GSM-7 表示
+func IsGSM7Char(r rune) bool {
+	return GSM7Septets(r) > 0
+}
+
+// CountGSM7 按 GSM-7 计算 septet 数
+// 返回 septet 总数，以及无法用 GSM-7 表示的字符（按出现顺序去重）；
+// 无法表示的字符在发送时会被短信中心替换为 '?'，因此按1个 septet 计算
+func CountGSM7(text string) (int, []string) {
+	septets := 0
+	var unsupported []string
+	seen := make(map[rune]bool)
+
+	for _, r := range text {
+		n := GSM7Septets(r)
+		if n == 0 {
+			if !seen[r] {
+				seen[r] = true
+				unsupported = append(unsupported, string(r))
+			}
+			n = 1
+		}
+		septets += n
+	}
+
+	return septets, unsupported
+}
+
+// IsGSM7 判断文本是否全部可以用 GSM-7 表示
+func IsGSM7(text string) bool {
+	for _, r := range text {
+		if !IsGSM7Char(r) {
+			return false
+		}
+	}
+	return true
+}
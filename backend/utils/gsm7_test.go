@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGSM7Septets(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{'A', 1},
+		{'@', 1},
+		{'£', 1},
+		{'Δ', 1},
+		{'\n', 1},
+		{'€', 2},
+		{'[', 2},
+		{'\\', 2},
+		{'\f', 2},
+		{'^', 2},
+		{0x1b, 0},
+		{'ç', 0},
+		{'中', 0},
+		{'😀', 0},
+	}
+	for _, tt := range tests {
+		if got := GSM7Septets(tt.r); got != tt.want {
+			t.Errorf("GSM7Septets(%q) = %d，期望 %d", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestCountGSM7(t *testing.T) {
+	tests := []struct {
+		text        string
+		septets     int
+		unsupported []string
+	}{
+		{text: "", septets: 0},
+		{text: "Hello", septets: 5},
+		{text: "Price: 5€", septets: 10},
+		{text: "{[]}", septets: 8},
+		{text: "Hi 你好你", septets: 6, unsupported: []string{"你", "好"}},
+	}
+	for _, tt := range tests {
+		septets, unsupported := CountGSM7(tt.text)
+		if septets != tt.septets || !reflect.DeepEqual(unsupported, tt.unsupported) {
+			t.Errorf("CountGSM7(%q) = %d, %q，期望 %d, %q", tt.text, septets, unsupported, tt.septets, tt.unsupported)
+		}
+	}
+}
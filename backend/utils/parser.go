@@ -2,7 +2,6 @@ package utils
 
 import (
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -12,31 +11,95 @@ type SpeechResolver interface {
 	ResolveSpeechGroup(nameOrID string) ([]string, error)
 }
 
+// TemplateSegmentType 模板片段类型
+type TemplateSegmentType string
+
+const (
+	SegmentLiteral     TemplateSegmentType = "literal"     // 字面文本
+	SegmentPlaceholder TemplateSegmentType = "placeholder" // 占位符
+)
+
+// TemplateSegment 模板片段
+type TemplateSegment struct {
+	Type TemplateSegmentType `json:"type"`
+	Text string              `json:"text"`           // 字面文本（已处理转义），或占位符括号内的内容
+	Name string              `json:"name,omitempty"` // 命名占位符 (@name) 的名称
+	Slot int                 `json:"slot"`           // 占位符槽位（从0开始，同名占位符共用一个槽位），字面文本为 -1
+	Pos  Position            `json:"pos"`            // 片段在模板中的起始位置
+}
+
 // ParseTemplate 解析模板，返回位置列表和原始模板结构
 func ParseTemplate(template string) ([]string, []string, error) {
-	// 匹配所有括号内容
-	re := regexp.MustCompile(`\(([^)]+)\)`)
-	matches := re.FindAllStringSubmatch(template, -1)
-	
-	if len(matches) == 0 {
-		return nil, nil, fmt.Errorf("模板格式错误：未找到括号位置")
+	segments, err := ParseTemplateSegments(template)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var positions []string
 	var rawTemplate []string
-	
-	for _, match := range matches {
-		if len(match) < 2 {
+	for _, seg := range segments {
+		if seg.Type != SegmentPlaceholder {
 			continue
 		}
-		content := match[1]
-		positions = append(positions, content)
-		rawTemplate = append(rawTemplate, match[0]) // 保存原始括号格式
+		positions = append(positions, seg.Text)
+		rawTemplate = append(rawTemplate, "("+seg.Text+")") // 保存原始括号格式
 	}
 
 	return positions, rawTemplate, nil
 }
 
+// ParseTemplateSegments 将模板解析为字面文本与占位符交替的片段列表
+// 语法错误以 *TemplateError 返回，包含出错的行列位置
+func ParseTemplateSegments(template string) ([]TemplateSegment, error) {
+	segments, err := parseTemplateTokens(tokenizeTemplate(template))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, seg := range segments {
+		if seg.Type == SegmentPlaceholder {
+			return segments, nil
+		}
+	}
+	return nil, &TemplateError{Pos: Position{Line: 1, Column: 1}, Msg: "未找到括号位置"}
+}
+
+// TemplateSlots 返回每个槽位第一次出现的占位符片段，按槽位顺序排列
+func TemplateSlots(segments []TemplateSegment) []TemplateSegment {
+	var slots []TemplateSegment
+	for _, seg := range segments {
+		if seg.Type == SegmentPlaceholder && seg.Slot == len(slots) {
+			slots = append(slots, seg)
+		}
+	}
+	return slots
+}
+
+// JoinSegments 生成以分隔符连接 n 个占位符的片段列表（未提供模板时使用）
+func JoinSegments(n int, separator string) []TemplateSegment {
+	segments := make([]TemplateSegment, 0, 2*n)
+	for slot := 0; slot < n; slot++ {
+		if slot > 0 && separator != "" {
+			segments = append(segments, TemplateSegment{Type: SegmentLiteral, Text: separator, Slot: -1})
+		}
+		segments = append(segments, TemplateSegment{Type: SegmentPlaceholder, Slot: slot})
+	}
+	return segments
+}
+
+// RenderSegments 按片段列表渲染内容，第 i 个占位符替换为 values[i]
+func RenderSegments(segments []TemplateSegment, values []string) string {
+	var b strings.Builder
+	for _, seg := range segments {
+		if seg.Type == SegmentLiteral {
+			b.WriteString(seg.Text)
+		} else if seg.Slot < len(values) {
+			b.WriteString(values[seg.Slot])
+		}
+	}
+	return b.String()
+}
+
 // ExpandRange 展开范围值，如 "3-10" 返回 ["3", "4", "5", ..., "10"]
 func ExpandRange(rangeStr string) ([]string, error) {
 	parts := strings.Split(rangeStr, "-")
@@ -0,0 +1,80 @@
+package utils
+
+import "sayhi/backend/models"
+
+// 短信分段容量
+// 超过单条容量后，每段需要携带6字节的UDH（用户数据头）用于拼接，每段可用容量相应减少
+const (
+	GSM7SingleSegmentUnits = 160 // GSM-7 单条短信 septet 数
+	GSM7MultiSegmentUnits  = 153 // GSM-7 长短信每段 septet 数
+	UCS2SingleSegmentUnits = 70  // UCS-2 单条短信 UTF-16 单元数
+	UCS2MultiSegmentUnits  = 67  // UCS-2 长短信每段 UTF-16 单元数
+)
+
+// Segmentation 短信分段结果
+type Segmentation struct {
+	Encoding       models.EncodingType // 实际发送编码（GSM7 或 UCS2）
+	Units          int                 // 总计费单位数（GSM-7 为 septet，UCS-2 为 UTF-16 单元）
+	Segments       []models.SMSSegment // 每段的边界
+	RemainingUnits int                 // 最后一段剩余可用单位数
+	UCS2Chars      []string            // 导致改用 UCS-2 的字符
+}
+
+// DetectSMSEncoding 检测短信实际发送时使用的编码
+// 全部字符都能用 GSM-7 表示时使用 GSM-7，否则整条短信使用 UCS-2
+func DetectSMSEncoding(text string) models.EncodingType {
+	if IsGSM7(text) {
+		return models.EncodingGSM7
+	}
+	return models.EncodingUCS2
+}
+
+// SplitSegments 按实际发送编码计算短信分段
+// 分段边界不会拆开 GSM-7 扩展字符（转义符与字符必须在同一段内），也不会拆开 UTF-16 代理对
+func SplitSegments(text string) Segmentation {
+	encoding := DetectSMSEncoding(text)
+
+	single, multi := UCS2SingleSegmentUnits, UCS2MultiSegmentUnits
+	unitsOf := UTF16Units
+	if encoding == models.EncodingGSM7 {
+		single, multi = GSM7SingleSegmentUnits, GSM7MultiSegmentUnits
+		unitsOf = GSM7Septets
+	}
+
+	runes := []rune(text)
+	total := 0
+	for _, r := range runes {
+		total += unitsOf(r)
+	}
+
+	seg := Segmentation{
+		Encoding: encoding,
+		Units:    total,
+	}
+	if encoding == models.EncodingUCS2 {
+		seg.UCS2Chars = NonGSM7Chars(text)
+	}
+
+	// 单条短信即可容纳
+	if total <= single {
+		seg.Segments = []models.SMSSegment{{Start: 0, End: len(runes), Units: total}}
+		seg.RemainingUnits = single - total
+		return seg
+	}
+
+	current := models.SMSSegment{}
+	for i, r := range runes {
+		units := unitsOf(r)
+		if current.Units+units > multi {
+			current.End = i
+			seg.Segments = append(seg.Segments, current)
+			current = models.SMSSegment{Start: i}
+		}
+		current.Units += units
+	}
+	current.End = len(runes)
+	seg.Segments = append(seg.Segments, current)
+	seg.RemainingUnits = multi - current.Units
+
+	return seg
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"sayhi/backend/models"
+	"strings"
+	"testing"
+)
+
+func TestSplitSegments(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		encoding  models.EncodingType
+		units     int
+		segments  []int // 每段的单位数
+		remaining int
+	}{
+		{name: "空内容", text: "", encoding: models.EncodingGSM7, units: 0, segments: []int{0}, remaining: 160},
+		{name: "GSM-7 单条上限", text: strings.Repeat("a", 160), encoding: models.EncodingGSM7, units: 160, segments: []int{160}, remaining: 0},
+		{name: "GSM-7 超出单条", text: strings.Repeat("a", 161), encoding: models.EncodingGSM7, units: 161, segments: []int{153, 8}, remaining: 145},
+		{name: "GSM-7 三段", text: strings.Repeat("a", 306), encoding: models.EncodingGSM7, units: 306, segments: []int{153, 153}, remaining: 0},
+		// 扩展字符不能被拆开：第153个 septet 处放不下 ESC+€，整体移到下一段
+		{name: "GSM-7 扩展字符不拆开", text: strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10), encoding: models.EncodingGSM7, units: 164, segments: []int{152, 12}, remaining: 141},
+		{name: "UCS-2 单条上限", text: strings.Repeat("中", 70), encoding: models.EncodingUCS2, units: 70, segments: []int{70}, remaining: 0},
+		{name: "UCS-2 超出单条", text: strings.Repeat("中", 71), encoding: models.EncodingUCS2, units: 71, segments: []int{67, 4}, remaining: 63},
+		// 代理对不能被拆开：第67个单元处放不下 emoji，整体移到下一段
+		{name: "UCS-2 代理对不拆开", text: strings.Repeat("中", 66) + "😀" + "中", encoding: models.EncodingUCS2, units: 69, segments: []int{69}, remaining: 1},
+		{name: "UCS-2 代理对跨段", text: strings.Repeat("中", 66) + "😀" + strings.Repeat("中", 5), encoding: models.EncodingUCS2, units: 73, segments: []int{66, 7}, remaining: 60},
+		{name: "一个字符导致改用 UCS-2", text: strings.Repeat("a", 100) + "ç", encoding: models.EncodingUCS2, units: 101, segments: []int{67, 34}, remaining: 33},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seg := SplitSegments(tt.text)
+			if seg.Encoding != tt.encoding {
+				t.Errorf("编码 %s，期望 %s", seg.Encoding, tt.encoding)
+			}
+			if seg.Units != tt.units {
+				t.Errorf("单位数 %d，期望 %d", seg.Units, tt.units)
+			}
+			if seg.RemainingUnits != tt.remaining {
+				t.Errorf("剩余单位数 %d，期望 %d", seg.RemainingUnits, tt.remaining)
+			}
+			if len(seg.Segments) != len(tt.segments) {
+				t.Fatalf("分段 %+v，期望各段单位数 %v", seg.Segments, tt.segments)
+			}
+
+			runes := []rune(tt.text)
+			next := 0
+			for i, s := range seg.Segments {
+				if s.Units != tt.segments[i] {
+					t.Errorf("第 %d 段单位数 %d，期望 %d", i, s.Units, tt.segments[i])
+				}
+				if s.Start != next || s.End < s.Start {
+					t.Errorf("第 %d 段边界 [%d, %d) 不连续", i, s.Start, s.End)
+				}
+				next = s.End
+			}
+			if next != len(runes) {
+				t.Errorf("最后一段结束于 %d，内容共 %d 个字符", next, len(runes))
+			}
+		})
+	}
+}
+
+func TestSplitSegmentsUCS2Chars(t *testing.T) {
+	seg := SplitSegments("Hi ç and 中 and ç")
+	if len(seg.UCS2Chars) != 2 || seg.UCS2Chars[0] != "ç" || seg.UCS2Chars[1] != "中" {
+		t.Errorf("UCS2Chars = %q", seg.UCS2Chars)
+	}
+	if seg := SplitSegments("Hello"); seg.UCS2Chars != nil {
+		t.Errorf("GSM-7 内容的 UCS2Chars = %q", seg.UCS2Chars)
+	}
+}
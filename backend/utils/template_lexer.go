@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 模板词法：
+//   文本        任意字符，\( \) \\ 分别表示字面的 ( ) \
+//   (           占位符开始
+//   )           占位符结束
+// 占位符不能嵌套，也不能为空；(@name) 为命名占位符，同名占位符共用同一个槽位
+
+// Position 模板中的位置，行和列从1开始，列按字符计
+type Position struct {
+	Offset int `json:"offset"` // 字节偏移
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// TemplateError 模板语法错误，携带出错位置
+type TemplateError struct {
+	Pos Position
+	Msg string
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("模板格式错误（第%d行第%d列）：%s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// templateTokenType 词法单元类型
+type templateTokenType int
+
+const (
+	tokenText templateTokenType = iota
+	tokenOpen
+	tokenClose
+)
+
+// templateToken 词法单元
+type templateToken struct {
+	typ  templateTokenType
+	text string // 文本单元的内容（已处理转义）
+	pos  Position
+}
+
+// tokenizeTemplate 将模板切分为文本、左括号、右括号三类词法单元
+func tokenizeTemplate(template string) []templateToken {
+	var tokens []templateToken
+	var text strings.Builder
+	var textPos Position
+	inText := false
+
+	pos := Position{Offset: 0, Line: 1, Column: 1}
+	flushText := func() {
+		if inText {
+			tokens = append(tokens, templateToken{typ: tokenText, text: text.String(), pos: textPos})
+			text.Reset()
+			inText = false
+		}
+	}
+	startText := func() {
+		if !inText {
+			textPos = pos
+			inText = true
+		}
+	}
+
+	runes := []rune(template)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		width := len(string(r))
+
+		switch {
+		case r == '\\' && i+1 < len(runes) && (runes[i+1] == '(' || runes[i+1] == ')' || runes[i+1] == '\\'):
+			startText()
+			text.WriteRune(runes[i+1])
+			pos.Offset += width + 1
+			pos.Column += 2
+			i++
+			continue
+		case r == '(':
+			flushText()
+			tokens = append(tokens, templateToken{typ: tokenOpen, pos: pos})
+		case r == ')':
+			flushText()
+			tokens = append(tokens, templateToken{typ: tokenClose, pos: pos})
+		default:
+			startText()
+			text.WriteRune(r)
+		}
+
+		pos.Offset += width
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	flushText()
+
+	return tokens
+}
+
+// parseTemplateTokens 将词法单元解析为模板片段（语法树的叶子节点序列）
+func parseTemplateTokens(tokens []templateToken) ([]TemplateSegment, error) {
+	var segments []TemplateSegment
+	slot := 0
+	namedSlots := make(map[string]int)
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.typ {
+		case tokenText:
+			segments = append(segments, TemplateSegment{Type: SegmentLiteral, Text: tok.text, Slot: -1, Pos: tok.pos})
+
+		case tokenClose:
+			return nil, &TemplateError{Pos: tok.pos, Msg: "多余的右括号"}
+
+		case tokenOpen:
+			// 收集到右括号为止的内容
+			var content strings.Builder
+			closed := false
+			for i+1 < len(tokens) {
+				i++
+				next := tokens[i]
+				if next.typ == tokenClose {
+					closed = true
+					break
+				}
+				if next.typ == tokenOpen {
+					return nil, &TemplateError{Pos: next.pos, Msg: "占位符中不能嵌套括号，字面括号请写作 \\( 或 \\)"}
+				}
+				content.WriteString(next.text)
+			}
+			if !closed {
+				return nil, &TemplateError{Pos: tok.pos, Msg: "括号未闭合"}
+			}
+			if strings.TrimFunc(content.String(), unicode.IsSpace) == "" {
+				return nil, &TemplateError{Pos: tok.pos, Msg: "占位符内容为空"}
+			}
+
+			seg := TemplateSegment{Type: SegmentPlaceholder, Text: content.String(), Pos: tok.pos}
+			if name, named := placeholderName(seg.Text); named {
+				if !IsValidPlaceholderName(name) {
+					return nil, &TemplateError{Pos: tok.pos, Msg: "占位符名称只能包含字母、数字、下划线和短横线"}
+				}
+				seg.Name = name
+				if existing, exists := namedSlots[name]; exists {
+					// 同名占位符复用已有槽位
+					seg.Slot = existing
+					segments = append(segments, seg)
+					continue
+				}
+				namedSlots[name] = slot
+			}
+			seg.Slot = slot
+			segments = append(segments, seg)
+			slot++
+		}
+	}
+
+	return segments, nil
+}
+
+// placeholderName 判断占位符是否为命名占位符 (@name)，返回名称
+func placeholderName(content string) (string, bool) {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "@") {
+		return "", false
+	}
+	return trimmed[1:], true
+}
+
+// IsValidPlaceholderName 检查占位符名称是否合法（字母、数字、下划线和短横线），位置标识也遵循同样的规则
+func IsValidPlaceholderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
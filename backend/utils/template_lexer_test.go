@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTemplateSegments(t *testing.T) {
+	type seg struct {
+		typ  TemplateSegmentType
+		text string
+		name string
+		slot int
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     []seg
+	}{
+		{
+			name:     "字面文本和占位符",
+			template: "Hi (a), visit (b)!",
+			want: []seg{
+				{SegmentLiteral, "Hi ", "", -1},
+				{SegmentPlaceholder, "a", "", 0},
+				{SegmentLiteral, ", visit ", "", -1},
+				{SegmentPlaceholder, "b", "", 1},
+				{SegmentLiteral, "!", "", -1},
+			},
+		},
+		{
+			name:     "转义括号和反斜杠",
+			template: `\(x\) (1-3) \\`,
+			want: []seg{
+				{SegmentLiteral, "(x) ", "", -1},
+				{SegmentPlaceholder, "1-3", "", 0},
+				{SegmentLiteral, ` \`, "", -1},
+			},
+		},
+		{
+			name:     "同名占位符共用槽位",
+			template: "(@name)(x)(@name)",
+			want: []seg{
+				{SegmentPlaceholder, "@name", "name", 0},
+				{SegmentPlaceholder, "x", "", 1},
+				{SegmentPlaceholder, "@name", "name", 0},
+			},
+		},
+		{
+			name:     "其它反斜杠保持原样",
+			template: `a\b(c)`,
+			want: []seg{
+				{SegmentLiteral, `a\b`, "", -1},
+				{SegmentPlaceholder, "c", "", 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments, err := ParseTemplateSegments(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(segments) != len(tt.want) {
+				t.Fatalf("得到 %d 个片段 %+v，期望 %d 个", len(segments), segments, len(tt.want))
+			}
+			for i, s := range segments {
+				got := seg{s.Type, s.Text, s.Name, s.Slot}
+				if got != tt.want[i] {
+					t.Errorf("第 %d 个片段 %+v，期望 %+v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseTemplateSegmentsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		line     int
+		column   int
+		offset   int
+	}{
+		{name: "多余的右括号", template: "abc)", line: 1, column: 4, offset: 3},
+		{name: "括号未闭合", template: "ab\ncd (x", line: 2, column: 4, offset: 6},
+		{name: "嵌套括号", template: "(a(b))", line: 1, column: 3, offset: 2},
+		{name: "空占位符", template: "x ( ) y", line: 1, column: 3, offset: 2},
+		{name: "非法名称", template: "(@a b)", line: 1, column: 1, offset: 0},
+		{name: "没有占位符", template: "plain text", line: 1, column: 1, offset: 0},
+		// 列按字符计，偏移按字节计
+		{name: "多字节字符后的错误", template: "မင်္ဂလာ)", line: 1, column: 8, offset: 21},
+		{name: "转义之后的错误", template: `\(\)x)`, line: 1, column: 6, offset: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTemplateSegments(tt.template)
+			var templateErr *TemplateError
+			if !errors.As(err, &templateErr) {
+				t.Fatalf("期望 *TemplateError，得到 %v", err)
+			}
+			pos := templateErr.Pos
+			if pos.Line != tt.line || pos.Column != tt.column || pos.Offset != tt.offset {
+				t.Errorf("错误位置 %+v，期望第%d行第%d列（偏移 %d）", pos, tt.line, tt.column, tt.offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// conversionRule 转换规则：将匹配 from 的内容替换为 to（支持 ${n} 引用分组）
+type conversionRule struct {
+	from *regexp.Regexp
+	to   string
+}
+
+func rule(from, to string) conversionRule {
+	return conversionRule{from: regexp.MustCompile(from), to: to}
+}
+
+// applyRules 按顺序依次应用转换规则
+func applyRules(text string, rules []conversionRule) string {
+	for _, r := range rules {
+		text = r.from.ReplaceAllString(text, r.to)
+	}
+	return text
+}
+
+// zawgyiToUnicodeRules Zawgyi → Unicode 转换规则
+// 顺序很重要：先把 Zawgyi 专有码位映射为 Unicode 码位（先映射中置符，再展开叠字和合字，
+// 避免展开结果被再次映射），最后按 Unicode 存储顺序重排前置元音、中置符和元音
+var zawgyiToUnicodeRules = []conversionRule{
+	// 中置符和 asat（Zawgyi 与 Unicode 的码位错开一位，需从高到低映射）
+	rule("[ွႇ]", "ှ"),
+	rule("ြ", "ွ"),
+	rule("[ျၾ-ႄ]", "ြ"),
+	rule("[်ၽ]", "ျ"),
+	rule("္", "်"),
+
+	// 叠字（下叠辅音）
+	rule("ၠ", "္က"),
+	rule("ၡ", "္ခ"),
+	rule("ၢ", "္ဂ"),
+	rule("ၣ", "္ဃ"),
+	rule("ၥ", "္စ"),
+	rule("[ၦၧ]", "္ဆ"),
+	rule("ၨ", "္ဇ"),
+	rule("ၩ", "္ဈ"),
+	rule("ၬ", "္ဋ"),
+	rule("ၭ", "္ဌ"),
+	rule("ၰ", "္ဏ"),
+	rule("[ၱၲ]", "္တ"),
+	rule("[ၳၴ]", "္ထ"),
+	rule("ၵ", "္ဒ"),
+	rule("ၶ", "္ဓ"),
+	rule("ၷ", "္န"),
+	rule("ၸ", "္ပ"),
+	rule("ၹ", "္ဖ"),
+	rule("ၺ", "္ဗ"),
+	rule("[ၻ႓]", "္ဘ"),
+	rule("ၼ", "္မ"),
+	rule("ႅ", "္လ"),
+	rule("႖", "္တွ"),
+
+	// 合字和变体字形
+	rule("ၪ", "ဉ"),
+	rule("ၫ", "ည"),
+	rule("ၮ", "ဍ္ဍ"),
+	rule("ၯ", "ဍ္ဎ"),
+	rule("႑", "ဏ္ဍ"),
+	rule("႒", "ဋ္ဌ"),
+	rule("႗", "ဋ္ဋ"),
+	rule("ႏ", "န"),
+	rule("႐", "ရ"),
+	rule("ႆ", "ဿ"),
+	rule("ဳ", "ု"),
+	rule("ဴ", "ူ"),
+	rule("ႈ", "ှု"),
+	rule("ႉ", "ှူ"),
+	rule("ႊ", "ွှ"),
+	rule("ႎ", "ိံ"),
+	rule("[႔႕]", "့"),
+	rule("ၚ", "ါ်"),
+	rule("၎", "၎င်း"),
+
+	// Zawgyi 常用数字 ၀ 代替字母 ဝ：不与数字相邻、后面跟着缅甸文字母或符号时视为 ဝ（需在重排之前进行）
+	rule("(^|[^၀-၉])၀([က-ဿ])", "${1}ဝ${2}"),
+
+	// kinzi：Zawgyi 写在辅音之后，Unicode 写在辅音之前
+	rule("(ေ)?(ြ)?([က-အ])ၤ", "င်္${3}${2}${1}"),
+	rule("(ေ)?(ြ)?([က-အ])ႋ", "င်္${3}${2}${1}ိ"),
+	rule("(ေ)?(ြ)?([က-အ])ႌ", "င်္${3}${2}${1}ီ"),
+	rule("(ေ)?(ြ)?([က-အ])ႍ", "င်္${3}${2}${1}ံ"),
+
+	// 前置元音 ေ 和中置符 ြ：Zawgyi 写在辅音之前，Unicode 写在辅音（及叠字）之后
+	rule("(ေ)?(ြ)?([က-အ])((?:္[က-အ])?)", "${3}${4}${2}${1}"),
+	rule("ေ([ျြွှ]+)", "${1}ေ"),
+
+	// 中置符按 ျ ြ ွ ှ 排序
+	rule("ြျ", "ျြ"),
+	rule("([ွှ])ျ", "ျ${1}"),
+	rule("ွြ", "ြွ"),
+	rule("ှွ", "ွှ"),
+
+	// 元音排序：中置符 → 上元音 → 下元音 → ာ → ံ → ့ → ်
+	rule("([ိီဲံ])([ျ-ှ]+)", "${2}${1}"),
+	rule("([ုူ])([ိီဲ])", "${2}${1}"),
+	rule("([ါာ])([ိီုူဲ])", "${2}${1}"),
+	rule("ံ([ိီုူဲ])", "${1}ံ"),
+	rule("့([ါ-ဲံ]+)", "${1}့"),
+	rule("့်", "့်"),
+	rule("ုု", "ု"),
+
+	// 常见的字形替代写法
+	rule("ဥ([ာ်])", "ဉ${1}"),
+	rule("ဦ", "ဦ"),
+	rule("စျ", "ဈ"),
+	rule("၀([ါာံ])", "ဝ${1}"),
+	rule("၄င်း", "၎င်း"),
+}
+
+// unicodeToZawgyiRules Unicode → Zawgyi 转换规则
+// 先在 Unicode 码位上完成合字和重排，再把中置符和叠字映射为 Zawgyi 码位
+var unicodeToZawgyiRules = []conversionRule{
+	rule("၎င်း", "၎"),
+	rule("ဦ", "ဦ"),
+
+	// kinzi（可与 ိ ီ ံ 合成一个字形）
+	rule("င်္([က-အ])((?:[ျ-ှ]|ေ)*)ိ", "${1}ႋ${2}"),
+	rule("င်္([က-အ])((?:[ျ-ှ]|ေ)*)ီ", "${1}ႌ${2}"),
+	rule("င်္([က-အ])((?:[ျ-ှ]|ေ)*)ံ", "${1}ႍ${2}"),
+	rule("င်္([က-အ])", "${1}ၤ"),
+
+	// 前置元音 ေ 和中置符 ြ 移到辅音之前
+	rule("([က-အ])((?:္[က-အ])?)([ၤႋႌႍ]?)(ျ)?(ြ)?(ွ)?(ှ)?(ေ)?", "${8}${5}${1}${2}${3}${4}${6}${7}"),
+
+	// 合字
+	rule("ှု", "ႈ"),
+	rule("ှူ", "ႉ"),
+	rule("ွှ", "ႊ"),
+	rule("ိံ", "ႎ"),
+	rule("ါ်", "ၚ"),
+	rule("ဍ္ဍ", "ၮ"),
+	rule("ဍ္ဎ", "ၯ"),
+	rule("ဏ္ဍ", "႑"),
+	rule("ဋ္ဌ", "႒"),
+	rule("ဋ္ဋ", "႗"),
+	rule("္တွ", "႖"),
+	rule("ဿ", "ႆ"),
+
+	// 叠字
+	rule("္က", "ၠ"),
+	rule("္ခ", "ၡ"),
+	rule("္ဂ", "ၢ"),
+	rule("္ဃ", "ၣ"),
+	rule("္စ", "ၥ"),
+	rule("္ဆ", "ၦ"),
+	rule("္ဇ", "ၨ"),
+	rule("္ဈ", "ၩ"),
+	rule("္ဋ", "ၬ"),
+	rule("္ဌ", "ၭ"),
+	rule("္ဏ", "ၰ"),
+	rule("္တ", "ၱ"),
+	rule("္ထ", "ၳ"),
+	rule("္ဒ", "ၵ"),
+	rule("္ဓ", "ၶ"),
+	rule("္န", "ၷ"),
+	rule("္ပ", "ၸ"),
+	rule("္ဖ", "ၹ"),
+	rule("္ဗ", "ၺ"),
+	rule("္ဘ", "ၻ"),
+	rule("္မ", "ၼ"),
+	rule("္လ", "ႅ"),
+
+	// 中置符和 asat（需从低到高映射，避免重复映射）
+	rule("်", "္"),
+	rule("ျ", "်"),
+	rule("ြ", "ျ"),
+	rule("ွ", "ြ"),
+	rule("ှ", "ွ"),
+
+	// ျ 下方还有 ွ、ှ 时改用短的 ya 中置符字形（如 ကျွန် → ကၽြန္），需在中置符映射为 Zawgyi 码位之后进行
+	rule("်([ြွႊ])", "ၽ${1}"),
+}
+
+// containsMyanmar 判断文本是否包含缅甸文字符
+func containsMyanmar(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool {
+		return r >= 0x1000 && r <= 0x109f
+	}) >= 0
+}
+
+// ZawgyiToUnicode 将 Zawgyi 编码的缅甸文转换为标准 Unicode
+func ZawgyiToUnicode(text string) string {
+	if !containsMyanmar(text) {
+		return text
+	}
+	return applyRules(text, zawgyiToUnicodeRules)
+}
+
+// UnicodeToZawgyi 将标准 Unicode 缅甸文转换为 Zawgyi 编码
+func UnicodeToZawgyi(text string) string {
+	if !containsMyanmar(text) {
+		return text
+	}
+	return applyRules(text, unicodeToZawgyiRules)
+}
@@ -0,0 +1,75 @@
+package utils
+
+import "testing"
+
+// 常用词的 Unicode 与 Zawgyi 写法
+var zawgyiPairs = []struct {
+	unicode string
+	zawgyi  string
+}{
+	{"ကျွန်ုပ်", "ကၽြန္ုပ္"},
+	{"ကျွန်တော်", "ကၽြန္ေတာ္"},
+	{"လျှော့", "ေလၽွာ့"},
+	{"လျှင်", "လၽွင္"},
+	{"မြန်မာ", "ျမန္မာ"},
+	{"ကျေးဇူး", "ေက်းဇူး"},
+	{"နေကောင်းလား", "ေနေကာင္းလား"},
+	{"မင်္ဂလာပါ", "မဂၤလာပါ"},
+	{"ပြည်", "ျပည္"},
+	{"သွား", "သြား"},
+	{"ရှိ", "ရွိ"},
+	{"အားလုံး", "အားလုံး"},
+	{"Hello 123", "Hello 123"},
+	{"၁၀၀ ကျပ်", "၁၀၀ က်ပ္"},
+}
+
+// Zawgyi 文本中常见的替代写法
+func TestZawgyiToUnicodeVariants(t *testing.T) {
+	tests := []struct {
+		zawgyi  string
+		unicode string
+	}{
+		{"၀န္ေဆာင္မႈ", "ဝန်ဆောင်မှု"},
+		{"ေ၀း", "ဝေး"},
+		{"စိတ္၀င္စား", "စိတ်ဝင်စား"},
+		{"၂၀၂၆", "၂၀၂၆"},
+		{"ေ႐ႊ", "ရွှေ"},
+		{"ေႂကြး", "ကြွေး"},
+		{"ၿမီ", "မြီ"},
+		{"ယေန႔", "ယနေ့"},
+	}
+	for _, tt := range tests {
+		if got := ZawgyiToUnicode(tt.zawgyi); got != tt.unicode {
+			t.Errorf("ZawgyiToUnicode(%q) = %q，期望 %q", tt.zawgyi, got, tt.unicode)
+		}
+	}
+}
+
+func TestUnicodeToZawgyi(t *testing.T) {
+	for _, p := range zawgyiPairs {
+		if got := UnicodeToZawgyi(p.unicode); got != p.zawgyi {
+			t.Errorf("UnicodeToZawgyi(%q) = %q，期望 %q", p.unicode, got, p.zawgyi)
+		}
+	}
+}
+
+func TestZawgyiToUnicode(t *testing.T) {
+	for _, p := range zawgyiPairs {
+		if got := ZawgyiToUnicode(p.zawgyi); got != p.unicode {
+			t.Errorf("ZawgyiToUnicode(%q) = %q，期望 %q", p.zawgyi, got, p.unicode)
+		}
+	}
+}
+
+func TestZawgyiRoundTrip(t *testing.T) {
+	words := []string{
+		"ကျွန်ုပ်တို့", "ကျွှ", "ချွေး", "ဆွေမျိုး", "ကြွက်", "ကျောင်း", "ငွေ", "မှာ", "နှုတ်", "သင်္ချိုင်း",
+		"အင်္ဂလိပ်", "ပြော", "တွေ့", "ဘယ်လို", "ဈေး", "ကျွန်းစု",
+	}
+	for _, w := range words {
+		z := UnicodeToZawgyi(w)
+		if back := ZawgyiToUnicode(z); back != w {
+			t.Errorf("%q → %q → %q，往返转换结果不一致", w, z, back)
+		}
+	}
+}
@@ -50,6 +50,174 @@ export const generateTemplate = (data) => {
   return api.post('/template/generate', data)
 }
 
+// 导出生成结果（format：csv、xlsx、txt、ndjson），返回文件内容；导出可能耗时较长，不设置超时
+export const exportTemplate = (data, format, bom) => {
+  return api.post('/template/export', data, {
+    params: { format, bom },
+    responseType: 'blob',
+    timeout: 0
+  })
+}
+
+// 解析模板（返回片段或带行列位置的语法错误）
+export const parseTemplate = (template) => {
+  return api.post('/template/parse', { template })
+}
+
+// 获取已保存的模板列表
+export const getTemplates = () => {
+  return api.get('/templates')
+}
+
+// 获取已保存的模板
+export const getTemplate = (id) => {
+  return api.get(`/templates/${id}`)
+}
+
+// 保存模板
+export const createTemplate = (data) => {
+  return api.post('/templates', data)
+}
+
+// 更新模板
+export const updateTemplate = (id, data) => {
+  return api.put(`/templates/${id}`, data)
+}
+
+// 删除模板
+export const deleteTemplate = (id) => {
+  return api.delete(`/templates/${id}`)
+}
+
+// 获取模板的历史版本
+export const getTemplateVersions = (id) => {
+  return api.get(`/templates/${id}/versions`)
+}
+
+// 比较模板的两个版本（to 省略时与最新版本比较）
+export const diffTemplateVersions = (id, from, to) => {
+  return api.get(`/templates/${id}/diff`, { params: { from, to } })
+}
+
+// 将模板恢复为指定版本
+export const restoreTemplateVersion = (id, version) => {
+  return api.post(`/templates/${id}/versions/${version}/restore`)
+}
+
+// 获取模板约束规则
+export const getTemplateRules = (templateId) => {
+  return api.get(`/templates/${templateId}/rules`)
+}
+
+// 设置模板约束规则（整体替换）
+export const setTemplateRules = (templateId, rules) => {
+  return api.put(`/templates/${templateId}/rules`, { rules })
+}
+
+// 提交异步生成任务（请求体与生成接口相同）
+export const createJob = (data) => {
+  return api.post('/jobs', data)
+}
+
+// 获取生成任务列表
+export const getJobs = () => {
+  return api.get('/jobs')
+}
+
+// 获取生成任务状态和进度
+export const getJob = (id) => {
+  return api.get(`/jobs/${id}`)
+}
+
+// 取消生成任务
+export const cancelJob = (id) => {
+  return api.post(`/jobs/${id}/cancel`)
+}
+
+// 删除生成任务及其结果
+export const deleteJob = (id) => {
+  return api.delete(`/jobs/${id}`)
+}
+
+// 分页获取生成任务的结果
+export const getJobResults = (id, offset = 0, limit = 100) => {
+  return api.get(`/jobs/${id}/results`, { params: { offset, limit } })
+}
+
+// 下载生成任务的结果（format：csv、xlsx、txt、ndjson），返回文件内容
+export const downloadJobResults = (id, format, bom) => {
+  return api.get(`/jobs/${id}/download`, {
+    params: { format, bom },
+    responseType: 'blob',
+    timeout: 0
+  })
+}
+
+// 订阅生成任务进度（Server-Sent Events）
+// EventSource 不能携带 Authorization 请求头，因此用 fetch 读取事件流；
+// onEvent(event, job) 在收到 progress、done 事件时调用，返回的函数用于停止订阅
+export const watchJob = (id, onEvent) => {
+  const { authState } = useAuth()
+  const controller = new AbortController()
+
+  fetch(`/api/jobs/${id}/events`, {
+    headers: { Authorization: `Bearer ${authState.token}` },
+    signal: controller.signal
+  }).then(async (response) => {
+    if (!response.ok) {
+      throw new Error(`订阅任务进度失败: ${response.status}`)
+    }
+    const reader = response.body.pipeThrough(new TextDecoderStream()).getReader()
+    let buffer = ''
+    for (;;) {
+      const { value, done } = await reader.read()
+      if (done) {
+        break
+      }
+      buffer += value
+      // 事件之间以空行分隔
+      let end
+      while ((end = buffer.indexOf('\n\n')) >= 0) {
+        const block = buffer.slice(0, end)
+        buffer = buffer.slice(end + 2)
+        let event = 'message'
+        let data = ''
+        for (const line of block.split('\n')) {
+          if (line.startsWith('event:')) {
+            event = line.slice(6).trim()
+          } else if (line.startsWith('data:')) {
+            data += line.slice(5)
+          }
+        }
+        if (data) {
+          onEvent(event, JSON.parse(data))
+        }
+      }
+    }
+  }).catch((error) => {
+    if (error.name !== 'AbortError') {
+      onEvent('error', { error: error.message })
+    }
+  })
+
+  return () => controller.abort()
+}
+
+// 查询当前用户的生成历史（from、to、page、pageSize 均可选）
+export const getHistory = (params) => {
+  return api.get('/history', { params })
+}
+
+// 获取单条生成历史（包含完整的生成参数）
+export const getHistoryRecord = (id) => {
+  return api.get(`/history/${id}`)
+}
+
+// 按生成历史中的参数重新生成
+export const rerunHistory = (id) => {
+  return api.post(`/history/${id}/rerun`)
+}
+
 // 获取所有位置值
 export const getAllPositions = () => {
   return api.get('/positions')
@@ -65,9 +233,9 @@ export const addPositionValue = (data) => {
   return api.post('/positions', data)
 }
 
-// 设置位置的所有值
-export const setPositionValues = (position, values) => {
-  return api.put(`/positions/${position}`, { values })
+// 设置位置的所有值（weights 可选，与 values 一一对应）
+export const setPositionValues = (position, values, weights) => {
+  return api.put(`/positions/${position}`, { values, weights })
 }
 
 // 删除位置值
@@ -75,6 +243,21 @@ export const deletePositionValue = (position, value) => {
   return api.delete(`/positions/${position}?value=${encodeURIComponent(value)}`)
 }
 
+// 创建位置
+export const createPosition = (data) => {
+  return api.post('/positions/definitions', data)
+}
+
+// 更新位置定义
+export const updatePosition = (position, data) => {
+  return api.put(`/positions/${position}/definition`, data)
+}
+
+// 删除位置（同时删除该位置的所有值）
+export const deletePosition = (position) => {
+  return api.delete(`/positions/${position}/definition`)
+}
+
 // 登录
 export const login = (data) => {
   return api.post('/auth/login', data)
@@ -111,3 +294,13 @@ export const deleteSpeechGroup = (id) => {
   return api.delete(`/speech-groups/${id}`)
 }
 
+
+// 缅甸文编码转换（Zawgyi ⇄ Unicode）
+export const convertMyanmar = (data) => {
+  return api.post('/convert/myanmar', data)
+}
+
+// 缅甸文编码检测（Zawgyi / Unicode）
+export const detectMyanmar = (texts) => {
+  return api.post('/convert/myanmar/detect', { texts })
+}